@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// sendExport responds with the user's data export as a zip download.
+func sendExport(c *gin.Context, user model.User) {
+	var archive bytes.Buffer
+	if err := services.ExportUserData(user, &archive); err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	filename := fmt.Sprintf("onichan-export-%s-%s.zip", user.Username, time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// ExportMe godoc
+// @Summary      Export the current user's data
+// @Description  Downloads a zip archive with the user's profile, preferences, posts, reactions, notifications, reports and sent messages as JSON, plus the uploaded images their posts and profile use.
+// @Tags         users
+// @Produce      application/zip
+// @Success      200  {file}    file
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to export data"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/export [get]
+func ExportMe(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	sendExport(c, user)
+}
+
+// DeleteMe godoc
+// @Summary      Delete the current user's account
+// @Description  Schedules the account for deletion after the grace period set by ACCOUNT_DELETION_GRACE_PERIOD. Until then the user can sign in and cancel it with POST /users/me/restore. Deleting anonymizes the account: posts and messages stay in place under a placeholder name, while the profile, credentials, reactions, notifications and reports are removed. Accounts with a password must confirm it.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      DeleteAccountRequest  false  "Current password"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Account scheduled for deletion", "deletion_scheduled_at": "..."}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid password"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to schedule account deletion"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me [delete]
+func DeleteMe(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload DeleteAccountRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.PasswordHash != "" && !services.CheckPassword(user, payload.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	deleteAt, err := services.ScheduleAccountDeletion(user)
+	if errors.Is(err, services.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Account scheduled for deletion",
+		"deletion_scheduled_at": deleteAt,
+	})
+}
+
+// RestoreMe godoc
+// @Summary      Cancel the current user's account deletion
+// @Description  Cancels a scheduled account deletion during the grace period.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"message": "Account deletion cancelled"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "no account deletion is scheduled"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to cancel account deletion"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/restore [post]
+func RestoreMe(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err := services.CancelAccountDeletion(user)
+	if errors.Is(err, services.ErrDeletionNotScheduled) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
+
+// ExportUser godoc
+// @Summary      Export a user's data
+// @Description  Downloads the same data export the user can request themselves, for handling legal requests.
+// @Tags         admin
+// @Produce      application/zip
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {file}    file
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to export data"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/export [get]
+func ExportUser(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionExportUser, user.ID, nil)
+
+	sendExport(c, user)
+}
+
+// DeleteUser godoc
+// @Summary      Delete a user's account
+// @Description  Deletes and anonymizes an account right away, without a grace period, for handling legal requests. The last user with every permission cannot be deleted.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Account deleted successfully"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to delete account"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil || user.Status == model.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canManageUser(c, user) {
+		return
+	}
+
+	err := services.DeleteAccount(user)
+	if errors.Is(err, services.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionDeleteUser, user.ID, map[string]interface{}{
+		"username": user.Username,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type MergeUsersRequest struct {
+	SourceID uint `json:"source_id" binding:"required"`
+}
+
+// ListUsers godoc
+// @Summary      List and search users
+// @Description  Returns a paginated list of accounts, newest first. q matches usernames and emails. Dates take the form 2006-01-02 or RFC 3339. banned=true lists users with an active ban and banned=false those without one.
+// @Tags         admin
+// @Produce      json
+// @Param        q               query     string  false  "Part of the username or email"
+// @Param        role            query     string  false  "Global role"
+// @Param        status          query     string  false  "Account status"
+// @Param        created_after   query     string  false  "Only accounts created after this date"
+// @Param        created_before  query     string  false  "Only accounts created before this date"
+// @Param        banned          query     bool    false  "Filter by active bans"
+// @Param        unverified      query     bool    false  "Only accounts with an unverified email"
+// @Param        page            query     int     false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"users": [...], "total_pages": X}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid created_after date"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve users"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users [get]
+func ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.User{})
+	if q := c.Query("q"); q != "" {
+		query = query.Where("username ILIKE ? OR email ILIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	for _, bound := range []struct {
+		param     string
+		condition string
+	}{
+		{"created_after", "created_at >= ?"},
+		{"created_before", "created_at < ?"},
+	} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		date, err := parseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.param + " date"})
+			return
+		}
+		query = query.Where(bound.condition, date)
+	}
+
+	activeBans := database.Database.Model(&model.Ban{}).Select("user_id").
+		Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	switch c.Query("banned") {
+	case "true":
+		query = query.Where("id IN (?)", activeBans)
+	case "false":
+		query = query.Where("id NOT IN (?)", activeBans)
+	}
+	if c.Query("unverified") == "true" {
+		query = query.Where("email_verified_at IS NULL")
+	}
+
+	var users []model.User
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	accounts := make([]model.Account, len(users))
+	for i, user := range users {
+		accounts[i] = user.Account()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       accounts,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// parseDate accepts a plain date or an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// canManageUser responds with an error when the signed-in user may not act on
+// target because it holds permissions they lack or is the last admin.
+func canManageUser(c *gin.Context, target model.User) bool {
+	err := services.CanManageUser(uint(c.MustGet("user_id").(float64)), c.GetString("role"), target)
+	if errors.Is(err, services.ErrTargetMorePrivileged) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	} else if errors.Is(err, services.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return false
+	}
+	return true
+}
+
+// GetModerationHistory godoc
+// @Summary      Get a user's moderation history
+// @Description  Returns the user's bans, their removed posts, reports on their posts and messages, and the admin actions taken on their account, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  model.ModerationHistory
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve moderation history"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/history [get]
+func GetModerationHistory(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	history, err := services.GetModerationHistory(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve moderation history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ChangeUsername godoc
+// @Summary      Change a user's username
+// @Description  Renames a user. Usernames take up to 31 characters and must be unique.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                    true  "User ID"
+// @Param        payload  body      ChangeUsernameRequest  true  "New username"
+// @Success      200  {object}  model.Account
+// @Failure      400  {object}  map[string]interface{}  "{"error": "username must be between 1 and 31 characters"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "username is already taken"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to change username"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/username [patch]
+func ChangeUsername(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil || user.Status == model.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canManageUser(c, user) {
+		return
+	}
+
+	var payload ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := user.Username
+	err := services.ChangeUsername(user, payload.Username)
+	if errors.Is(err, services.ErrInvalidUsername) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change username"})
+		return
+	}
+
+	database.Database.First(&user, user.ID)
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionChangeUsername, user.ID, map[string]interface{}{
+		"from": from,
+		"to":   user.Username,
+	})
+
+	c.JSON(http.StatusOK, user.Account())
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force a password reset
+// @Description  Clears the user's password, signs them out everywhere and emails them a link to choose a new password
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Password reset email sent"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to reset password"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/password-reset [post]
+func ForcePasswordReset(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil || user.Status == model.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canManageUser(c, user) {
+		return
+	}
+
+	if err := services.ForcePasswordReset(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionPasswordReset, user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset email sent"})
+}
+
+// RevokeUserSessions godoc
+// @Summary      Sign a user out everywhere
+// @Description  Revokes every session and API token of the user and closes their websocket connections.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Sessions revoked"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to revoke sessions"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/sessions [delete]
+func RevokeUserSessions(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canManageUser(c, user) {
+		return
+	}
+
+	if err := services.RevokeAllSessions(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionRevokeSessions, user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sessions revoked"})
+}
+
+// MergeUsers godoc
+// @Summary      Merge a duplicate account
+// @Description  Moves the posts, reactions, notifications and reports of the account source_id into this user, then deletes source_id. Reputation is recalculated afterwards.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true  "User ID to keep"
+// @Param        payload  body      MergeUsersRequest  true  "Duplicate account"
+// @Success      200  {object}  model.Account
+// @Failure      400  {object}  map[string]interface{}  "{"error": "cannot merge an account into itself"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to merge accounts"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/merge [post]
+func MergeUsers(c *gin.Context) {
+	var target model.User
+	if err := database.Database.First(&target, c.Param("id")).Error; err != nil || target.Status == model.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var payload MergeUsersRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var source model.User
+	if err := database.Database.First(&source, payload.SourceID).Error; err != nil || source.Status == model.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canManageUser(c, target) || !canManageUser(c, source) {
+		return
+	}
+
+	err := services.MergeUsers(target, source)
+	if errors.Is(err, services.ErrMergeSameUser) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge accounts"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionMergeUsers, target.ID, map[string]interface{}{
+		"source_id":       source.ID,
+		"source_username": source.Username,
+	})
+
+	database.Database.First(&target, target.ID)
+	c.JSON(http.StatusOK, target.Account())
+}
+
+// ListAuditLogs godoc
+// @Summary      List the audit log
+// @Description  Returns a paginated list of admin actions on user accounts, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        actor_id        query     int     false  "Only actions by this admin"
+// @Param        target_user_id  query     int     false  "Only actions on this user"
+// @Param        action          query     string  false  "Only this action"
+// @Param        page            query     int     false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"audit_logs": [...], "total_pages": X}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve audit log"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/audit-logs [get]
+func ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.AuditLog{})
+	if actorID := c.Query("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if targetUserID := c.Query("target_user_id"); targetUserID != "" {
+		query = query.Where("target_user_id = ?", targetUserID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var logs []model.AuditLog
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Preload("Actor").
+		Preload("TargetUser").
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_logs":  logs,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreateAPIToken godoc
+// @Summary      Create a personal API token
+// @Description  Creates a named token with the given scopes for scripts and bots, sent as `Authorization: Bearer oni_...`. The token is only returned once. A token only works on routes that accept one of its scopes; admin scopes need the matching permission (admin:reports needs resolve_reports, admin:categories needs manage_categories, admin:reactions needs manage_reactions). Leave expires_in_days empty for a token that does not expire.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        createAPITokenRequest  body      CreateAPITokenRequest  true  "Token name, scopes and lifetime"
+// @Success      201  {object}  map[string]interface{}  "{"token": "oni_...", "api_token": {...}}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid scope"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "admin scopes need the matching permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to create token"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/tokens [post]
+func CreateAPIToken(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(payload.Name) > 63 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must contain at most 63 characters"})
+		return
+	}
+	if len(payload.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+	if payload.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be positive"})
+		return
+	}
+
+	apiToken, rawToken, err := services.CreateAPIToken(userID, c.GetString("role"), payload.Name, payload.Scopes, time.Duration(payload.ExpiresInDays)*24*time.Hour)
+	if errors.Is(err, services.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "valid_scopes": model.APITokenScopes})
+		return
+	} else if errors.Is(err, services.ErrScopeNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":     rawToken,
+		"api_token": apiToken,
+	})
+}
+
+// ListAPITokens godoc
+// @Summary      List personal API tokens
+// @Description  Lists the current user's active API tokens with their scopes, expiry and last use. The tokens themselves are never returned.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   model.APIToken
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve tokens"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/tokens [get]
+func ListAPITokens(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	tokens, err := services.ListAPITokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeAPIToken godoc
+// @Summary      Revoke a personal API token
+// @Description  Revokes one of the current user's API tokens. It stops working immediately.
+// @Tags         auth
+// @Produce      json
+// @Param        id  path      int  true  "Token ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Token revoked successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Token not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to revoke token"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/tokens/{id} [delete]
+func RevokeAPIToken(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	err = services.RevokeAPIToken(userID, uint(tokenID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
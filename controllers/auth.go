@@ -1,25 +1,23 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"onichan/database"
 	"onichan/model"
 	"onichan/services"
 	"onichan/utils"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Email      string `json:"email" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	InviteCode string `json:"invite_code"`
 }
 
 type LoginRequest struct {
@@ -28,8 +26,9 @@ type LoginRequest struct {
 }
 
 type ChangePasswordRequest struct {
-	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required"`
+	OldPassword     string `json:"old_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	RevokeAPITokens bool   `json:"revoke_api_tokens"`
 }
 
 type ChangeEmailRequest struct {
@@ -42,6 +41,11 @@ type ForgotPasswordRequest struct {
 }
 
 type ResetForgottenPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
@@ -49,17 +53,26 @@ type ChangeAvatarRequest struct {
 	AvatarURL string `json:"avatar_url" binding:"required"`
 }
 
-var EMAIL_EXPIRATION, _ = strconv.Atoi(os.Getenv("EMAIL_EXPIRATION"))
+// validatePassword applies the password policy shared by every endpoint that
+// sets a password.
+func validatePassword(password string) (bool, string) {
+	if len(password) < 8 {
+		return false, "Password must contain atleast 8 characters"
+	}
+
+	return true, ""
+}
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Creates a new user with the provided username, email, and password.
+// @Description  Creates a new user with the provided username, email, and password. The account starts unverified and a verification link is emailed to the user. Depending on the registration_mode setting, an invite code is required (`invite`) or accounts registered without one wait for an admin to approve them before they can sign in (`approval`).
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        registerRequest  body      RegisterRequest  true  "Register user"
-// @Success      200  {object}    map[string]interface{}  "{"message": "User created successfully"}"
+// @Success      200  {object}    map[string]interface{}  "{"message": "User created successfully. Please check your email to verify your account"}"
 // @Failure      400  {object}    map[string]interface{}  "{"error": "Password must contain at least 8 characters"}"
+// @Failure      403  {object}    map[string]interface{}  "{"error": "an invite code is required to register"}"
 // @Failure      409  {object}    map[string]interface{}  "{"error": "Email already in use"}"
 // @Failure      500  {object}    map[string]interface{}  "{"error": "Could not hash password"}"
 // @Router       /auth/register [post]
@@ -71,8 +84,8 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	if len(payload.Password) < 8 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must contain atleast 8 characters"})
+	if ok, message := validatePassword(payload.Password); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
 		return
 	}
 
@@ -82,8 +95,7 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	salt := utils.GetToken(32)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(salt+payload.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(payload.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
 		return
@@ -94,32 +106,62 @@ func Register(c *gin.Context) {
 	user := model.User{
 		Username:     payload.Username,
 		Email:        payload.Email,
-		PasswordHash: string(hashedPassword),
-		Salt:         salt,
+		PasswordHash: hashedPassword,
 		AvatarURL:    &randomAvatar,
 	}
 
-	if result := database.Database.Create(&user); result.Error != nil {
-		if strings.Contains(result.Error.Error(), "duplicate key") {
-			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
-		}
+	if err := services.RegisterUser(&user, payload.InviteCode); err != nil {
+		registrationFailed(c, err)
+		return
+	}
+
+	if err := services.SendVerificationEmail(user); err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusOK, gin.H{"message": "User created successfully, but the verification email could not be sent. Please request a new one"})
+		return
+	}
+
+	if user.Status == model.UserStatusPending {
+		c.JSON(http.StatusOK, gin.H{"message": "User created successfully. Please check your email to verify your account. An admin has to approve your registration before you can sign in"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User created successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "User created successfully. Please check your email to verify your account"})
+}
+
+// registrationFailed responds to an error from creating an account.
+func registrationFailed(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInviteRequired) || errors.Is(err, services.ErrInvalidInvite) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	} else if strings.Contains(err.Error(), "duplicate key") {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	}
+}
+
+// registrationPending tells users whose registration still awaits approval
+// that they cannot sign in yet.
+func registrationPending(c *gin.Context, user model.User) bool {
+	if user.Status != model.UserStatusPending {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Your registration is awaiting approval by an admin"})
+	return true
 }
 
 // Login godoc
 // @Summary      Login a user
-// @Description  Authenticates a user with username and password and returns a JWT token.
+// @Description  Authenticates a user with username and password and starts a new session. Returns a short-lived access token and a refresh token, or a two-factor challenge if the account has two-factor authentication enabled. Browser clients can send `X-Auth-Mode: cookie` to receive the tokens as HttpOnly cookies instead, together with a CSRF token to send in the X-CSRF-Token header on state-changing requests; this applies to every endpoint that starts a session.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
+// @Param        X-Auth-Mode   header    string        false  "Set to cookie for cookie sessions"
 // @Param        loginRequest  body      LoginRequest  true  "Login user"
-// @Success      200  {object}  map[string]interface{}  "{"token": "your.jwt.token"}"
+// @Success      200  {object}  services.TokenPair
 // @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid username or password"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "Too many attempts, please try again later", "retry_after": 60}"
 // @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to generate token"}"
 // @Router       /auth/login [post]
 func Login(c *gin.Context) {
@@ -129,29 +171,77 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	accountKey := "login:account:" + strings.ToLower(payload.Username)
+	ipKey := "login:ip:" + c.ClientIP()
+	if throttled(c, accountKey, ipKey) {
+		return
+	}
+
 	var user model.User
 	if err := database.Database.First(&user, "username = ?", payload.Username).Error; err != nil {
+		recordFailure(nil, accountKey, ipKey)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(user.Salt+payload.Password)); err != nil {
+	if !services.CheckPassword(user, payload.Password) {
+		recordFailure(&user, accountKey, ipKey)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	token, err := utils.GenerateJWT(user.ID)
+	services.ClearThrottle(accountKey)
+	completeLogin(c, user)
+}
+
+// completeLogin finishes any sign-in once the user's first factor has been
+// checked. Accounts with two-factor authentication get a challenge to
+// exchange at /auth/2fa/verify instead of a session.
+func completeLogin(c *gin.Context, user model.User) {
+	if accountBanned(c, user.ID) || registrationPending(c, user) {
+		return
+	}
+
+	twoFactorEnabled, err := services.IsTwoFactorEnabled(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	if twoFactorEnabled {
+		challenge, err := utils.GenerateTwoFactorChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"two_factor_required": true,
+			"challenge":           challenge,
+		})
+		return
+	}
+
+	startSession(c, user)
+}
+
+func startSession(c *gin.Context, user model.User) {
+	if accountBanned(c, user.ID) || registrationPending(c, user) {
+		return
+	}
+
+	tokens, err := services.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	respondWithSession(c, tokens, cookieMode(c))
 }
 
 // ChangePassword godoc
 // @Summary      Change the current user's password
-// @Description  Allows a logged-in user to change their current password by providing the old password and a new password.
+// @Description  Allows a logged-in user to change their current password by providing the old password and a new password. All other sessions of the user are revoked. API tokens stay valid unless revoke_api_tokens is set.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -159,7 +249,7 @@ func Login(c *gin.Context) {
 // @Success      200  {object}  map[string]interface{}  "{"message": "Password updated successfully"}"
 // @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid old password"}"
 // @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
-// @Failure      500  {object}  map[string]interface{}  "{"error": "Could not hash password"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update password"}"
 // @Security     ApiKeyAuth
 // @Router       /auth/change-password [patch]
 func ChangePassword(c *gin.Context) {
@@ -177,32 +267,38 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(user.Salt+payload.OldPassword)); err != nil {
+	if !services.CheckPassword(user, payload.OldPassword) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid old password"})
 		return
 	}
 
-	salt := utils.GetToken(32)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(salt+payload.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
+	if ok, message := validatePassword(payload.NewPassword); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
 		return
 	}
 
-	if err := database.Database.Model(&user).Updates(map[string]interface{}{
-		"password_hash": string(hashedPassword),
-		"salt":          salt,
-	}).Error; err != nil {
+	if err := services.SetPassword(user, payload.NewPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
 
+	if err := services.RevokeUserSessions(user.ID, c.GetUint("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke other sessions"})
+		return
+	}
+	if payload.RevokeAPITokens {
+		if err := services.RevokeUserAPITokens(user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API tokens"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
 
 // ChangeEmail godoc
 // @Summary      Change the current user's email
-// @Description  Allows a logged-in user to change their email by providing current password and the new email.
+// @Description  Allows a logged-in user to change their email by providing current password and the new email. The new email has to be verified again.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -229,7 +325,7 @@ func ChangeEmail(c *gin.Context) {
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(user.Salt+payload.Password)); err != nil {
+	if !services.CheckPassword(user, payload.Password) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password"})
 		return
 	}
@@ -241,23 +337,33 @@ func ChangeEmail(c *gin.Context) {
 		return
 	}
 
-	if err := database.Database.Model(&user).Update("email", payload.Email).Error; err != nil {
+	if err := database.Database.Model(&user).Updates(map[string]interface{}{
+		"email":             payload.Email,
+		"email_verified_at": nil,
+	}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Email updated successfully"})
+	user.Email = payload.Email
+	if err := services.SendVerificationEmail(user); err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusOK, gin.H{"message": "Email updated successfully, but the verification email could not be sent. Please request a new one"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email updated successfully. Please check your email to verify it"})
 }
 
 // ChangeAvatar godoc
 // @Summary      Change the current user's avatar
-// @Description  Allows a logged-in user to change their avatar URL.
+// @Description  Allows a logged-in user to change their avatar to one of the gallery avatars listed by /users/avatars, or to one of the sizes of the avatar they uploaded.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        changeAvatarRequest  body      ChangeAvatarRequest  true  "Change Avatar URL"
 // @Success      200  {object}  map[string]interface{}  "{"message": "Avatar updated successfully"}"
-// @Failure      400  {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "avatar must be one of the gallery avatars or your own upload"}"
 // @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
 // @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update avatar"}"
 // @Security     ApiKeyAuth
@@ -277,7 +383,11 @@ func ChangeAvatar(c *gin.Context) {
 		return
 	}
 
-	if err := database.Database.Model(&user).Update("avatar_url", payload.AvatarURL).Error; err != nil {
+	err := services.SetAvatar(user, payload.AvatarURL)
+	if errors.Is(err, services.ErrAvatarNotAllowed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
 		return
 	}
@@ -287,15 +397,14 @@ func ChangeAvatar(c *gin.Context) {
 
 // ForgotPassword godoc
 // @Summary      Initiate password reset
-// @Description  Generates a token and sends a password-reset email to the user
+// @Description  Sends a single-use password-reset link to the user's email. The response is the same whether or not an account uses the email.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        ForgotPassword body ForgotPasswordRequest true "Forgot Password Payload"
-// @Success      200 {object} gin.H{"message": "Email sent"}
-// @Failure      400 {object} gin.H{"error": "Bad request"}
-// @Failure      404 {object} gin.H{"error": "User not found"}
-// @Failure      500 {object} gin.H{"error": "Internal server error"}
+// @Success      200  {object}  map[string]interface{}  "{"message": "If an account uses this email, a reset link has been sent"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "Too many attempts, please try again later"}"
 // @Router       /auth/forgot-password [post]
 func ForgotPassword(c *gin.Context) {
 	var payload ForgotPasswordRequest
@@ -304,40 +413,40 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	var user model.User
-	if err := database.Database.First(&user, "email = ?", payload.Email).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	emailKey := "forgot:email:" + strings.ToLower(payload.Email)
+	ipKey := "forgot:ip:" + c.ClientIP()
+	if throttled(c, emailKey, ipKey) {
 		return
 	}
 
-	token, err := utils.GenerateJWTForgotPassword(user.ID, user.PasswordHash)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
-	}
+	// Every request counts, so the reset mail cannot be used to flood an inbox.
+	recordFailure(nil, emailKey, ipKey)
 
-	err = services.SendEmail(user.Email, "Change Password", "Click the link to change your password: "+os.Getenv("FRONTEND_URL")+"/reset-password?token="+token)
-
-	if err != nil {
-		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send email"})
-		return
+	var user model.User
+	if err := database.Database.First(&user, "email = ?", payload.Email).Error; err == nil {
+		// Send in the background so the response time does not reveal
+		// whether the account exists.
+		go func() {
+			if err := services.SendPasswordResetEmail(user); err != nil {
+				fmt.Println(err)
+			}
+		}()
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Email sent"})
+	c.JSON(http.StatusOK, gin.H{"message": "If an account uses this email, a reset link has been sent"})
 }
 
 // ResetForgottenPassword godoc
 // @Summary      Reset a forgotten password
-// @Description  Validates the token, generates a new password, updates the password in the database, and returns the new password
+// @Description  Consumes the token from the password-reset email and sets the chosen password. The token can only be used once, and all sessions and API tokens of the user are revoked.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        ResetForgottenPassword body ResetForgottenPasswordRequest true "Reset Forgotten Password Payload"
-// @Success      200 {object} gin.H{"password": "newly-generated-password"}
-// @Failure      400 {object} gin.H{"error": "Invalid token or bad request"}
-// @Failure      404 {object} gin.H{"error": "User not found"}
-// @Failure      500 {object} gin.H{"error": "Failed to update password"}
+// @Success      200  {object}  map[string]interface{}  "{"message": "Password updated successfully"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid token or bad request"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update password"}"
 // @Router       /auth/reset-password [post]
 func ResetForgottenPassword(c *gin.Context) {
 	var payload ResetForgottenPasswordRequest
@@ -346,45 +455,116 @@ func ResetForgottenPassword(c *gin.Context) {
 		return
 	}
 
-	token, err := utils.ValidateJWT(payload.Token)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+	// Check the policy first so a weak password does not burn the token.
+	if ok, message := validatePassword(payload.NewPassword); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
 		return
 	}
 
-	claims := token.Claims.(jwt.MapClaims)
-	userID := uint(claims["user_id"].(float64))
-	hash := claims["hash"].(string)
-
-	fmt.Println(userID, hash)
+	token, err := services.ConsumeUserToken(payload.Token, model.TokenPurposePasswordReset)
+	if errors.Is(err, services.ErrInvalidUserToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+		return
+	}
 
 	var user model.User
-	if err := database.Database.First(&user, userID).Error; err != nil {
+	if err := database.Database.First(&user, token.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	if hash != user.PasswordHash {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+	if err := services.SetPassword(user, payload.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
 
-	password := utils.GetRandomAlphaString(8)
-	salt := utils.GetToken(32)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(salt+password), bcrypt.DefaultCost)
+	// The reset link was delivered to this address, which proves ownership.
+	if user.EmailVerifiedAt == nil {
+		if err := services.MarkEmailVerified(&user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+	}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not hash password"})
+	if err := services.RevokeUserSessions(user.ID, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if err := services.RevokeUserAPITokens(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API tokens"})
 		return
 	}
 
-	if err := database.Database.Model(&user).Updates(map[string]interface{}{
-		"password_hash": string(hashedPassword),
-		"salt":          salt,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify an email address
+// @Description  Consumes the token from the verification email and marks the account's email as verified.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        verifyEmailRequest  body      VerifyEmailRequest  true  "Verification token"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Email verified successfully"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid or expired token"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to verify email"}"
+// @Router       /auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var payload VerifyEmailRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := services.VerifyEmail(payload.Token); errors.Is(err, services.ErrInvalidUserToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"password": password})
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  Sends a new verification link to the current user's email. Previously sent links stop working. A new link can be requested every 5 minutes.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"message": "Email sent"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "Email is already verified"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "a verification email was sent recently, please wait a few minutes"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to send email"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/resend-verification [post]
+func ResendVerification(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	err := services.ResendVerificationEmail(user)
+	if errors.Is(err, services.ErrVerificationResendTooSoon) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email sent"})
 }
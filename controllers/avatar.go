@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAvatarUploadSize is the largest avatar file accepted, in bytes.
+const maxAvatarUploadSize = 5 << 20
+
+type AvatarRequest struct {
+	AvatarURL string `json:"avatar_url" binding:"required"`
+}
+
+// CreateAvatar godoc
+// @Summary      Add a gallery avatar
+// @Description  Adds an avatar users can pick. The URL must be an http(s) URL or an uploads/ path of at most 255 characters.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      AvatarRequest  true  "Avatar"
+// @Success      201  {object}  model.Avatar
+// @Failure      400  {object}  map[string]interface{}  "{"error": "avatar URL must be an http or https URL or an uploads/ path of at most 255 characters"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to create avatar"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/avatars [post]
+func CreateAvatar(c *gin.Context) {
+	var payload AvatarRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	avatar, err := services.CreateAvatar(payload.AvatarURL)
+	if errors.Is(err, services.ErrInvalidAvatarURL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create avatar"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, avatar)
+}
+
+// UpdateAvatar godoc
+// @Summary      Change a gallery avatar
+// @Description  Replaces the URL of a gallery avatar. Users wearing the avatar switch to the new URL.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int            true  "Avatar ID"
+// @Param        payload  body      AvatarRequest  true  "Avatar"
+// @Success      200  {object}  model.Avatar
+// @Failure      400  {object}  map[string]interface{}  "{"error": "avatar URL must be an http or https URL or an uploads/ path of at most 255 characters"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Avatar not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update avatar"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/avatars/{id} [put]
+func UpdateAvatar(c *gin.Context) {
+	var avatar model.Avatar
+	if err := database.Database.First(&avatar, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	var payload AvatarRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	avatar, err := services.UpdateAvatar(avatar, payload.AvatarURL)
+	if errors.Is(err, services.ErrInvalidAvatarURL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, avatar)
+}
+
+// DeleteAvatar godoc
+// @Summary      Remove a gallery avatar
+// @Description  Removes an avatar from the gallery. Users wearing it get a random one of the remaining avatars. The last gallery avatar cannot be removed.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      int  true  "Avatar ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Avatar deleted successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Avatar not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "the last gallery avatar cannot be removed"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to delete avatar"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/avatars/{id} [delete]
+func DeleteAvatar(c *gin.Context) {
+	var avatar model.Avatar
+	if err := database.Database.First(&avatar, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	err := services.DeleteAvatar(avatar)
+	if errors.Is(err, services.ErrLastAvatar) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Avatar deleted successfully"})
+}
+
+// UploadAvatar godoc
+// @Summary      Upload a custom avatar
+// @Description  Accepts a jpeg, png or gif image of up to 5 MB and 4096 by 4096 pixels via multipart/form-data. The image is cropped to a centered square and stored as 256, 128 and 64 pixel png files; the 256 pixel one becomes the avatar. The previously uploaded avatar is removed.
+// @Tags         users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "Avatar image"
+// @Success      200  {object}  map[string]interface{}  "{"avatar_url": "uploads/avatars/<file>", "sizes": {"256": "...", "128": "...", "64": "..."}}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "avatar must be a jpeg, png or gif image"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to save avatar"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/avatar [post]
+func UploadAvatar(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if header.Size > maxAvatarUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar must be at most 5 MB"})
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	urls, err := services.UploadAvatar(user, file)
+	if errors.Is(err, services.ErrInvalidAvatarImage) || errors.Is(err, services.ErrAvatarTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"avatar_url": urls[services.AvatarSizes[0]],
+		"sizes":      urls,
+	})
+}
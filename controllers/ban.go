@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type IssueBanRequest struct {
+	UserID        uint   `json:"user_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required"`
+	Scope         string `json:"scope" binding:"required"`
+	CategoryID    *uint  `json:"category_id"`
+	DurationHours int    `json:"duration_hours"`
+}
+
+// accountBanned responds with the ban notice when the user has a full ban.
+func accountBanned(c *gin.Context, userID uint) bool {
+	ban, err := services.GetAccountBan(userID)
+	if err != nil || ban.Scope != model.BanScopeFull {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": ban.Message(), "ban": ban.Notice()})
+	return true
+}
+
+// categoryBanned responds with the ban notice when the user may not write in
+// the category.
+func categoryBanned(c *gin.Context, userID, categoryID uint) bool {
+	ban, err := services.GetCategoryBan(userID, categoryID)
+	if err != nil {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": ban.Message(), "ban": ban.Notice()})
+	return true
+}
+
+// IssueBan godoc
+// @Summary      Ban a user
+// @Description  Bans a user with one of the scopes `full` (no access, ends their sessions), `read_only` (can sign in and read only) or `category` (cannot post or react in category_id). Leave duration_hours empty for a permanent ban. Full and read-only bans need the ban_users permission globally; category bans need it for that category. Users holding permissions the caller lacks cannot be banned. Read-only banned users can still sign out and revoke their API tokens.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      IssueBanRequest  true  "Ban"
+// @Success      201  {object}  model.Ban
+// @Failure      400  {object}  map[string]interface{}  "{"error": "scope must be full, read_only or category"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "this user cannot be banned"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to ban user"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/bans [post]
+func IssueBan(c *gin.Context) {
+	var payload IssueBanRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payload.DurationHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_hours must be positive"})
+		return
+	}
+
+	issuedByID := uint(c.MustGet("user_id").(float64))
+	categoryID := payload.CategoryID
+	if payload.Scope != model.BanScopeCategory {
+		categoryID = nil
+	}
+	if !services.HasPermission(issuedByID, c.GetString("role"), model.PermissionBanUsers, categoryID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to issue this ban"})
+		return
+	}
+
+	ban := model.Ban{
+		UserID:     payload.UserID,
+		Reason:     payload.Reason,
+		Scope:      payload.Scope,
+		CategoryID: payload.CategoryID,
+		IssuedByID: issuedByID,
+	}
+	if payload.DurationHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(payload.DurationHours) * time.Hour)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	ban, err := services.IssueBan(ban, c.GetString("role"))
+	if errors.Is(err, services.ErrInvalidBanScope) || errors.Is(err, services.ErrBanCategoryRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrCannotBanUser) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ban)
+}
+
+// ListBans godoc
+// @Summary      List bans
+// @Description  Returns a paginated list of bans, newest first. By default only active bans are listed.
+// @Tags         admin
+// @Produce      json
+// @Param        user_id  query     int   false  "Only bans of this user"
+// @Param        all      query     bool  false  "Include expired and lifted bans"
+// @Param        page     query     int   false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"bans": [...], "total_pages": X}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve bans"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/bans [get]
+func ListBans(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.Ban{})
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if c.Query("all") != "true" {
+		query = query.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+
+	var bans []model.Ban
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Preload("User").
+		Preload("IssuedBy").
+		Find(&bans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bans"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans":        bans,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// LiftBan godoc
+// @Summary      Lift a ban
+// @Description  Ends a ban before it expires
+// @Tags         admin
+// @Produce      json
+// @Param        id  path      int  true  "Ban ID"
+// @Success      200  {object}  model.Ban
+// @Failure      403  {object}  map[string]interface{}  "{"error": "You are not allowed to lift this ban"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Ban not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to lift ban"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/bans/{id} [delete]
+func LiftBan(c *gin.Context) {
+	var ban model.Ban
+	if err := database.Database.First(&ban, c.Param("id")).Error; err != nil || !ban.IsActive() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ban not found"})
+		return
+	}
+
+	liftedByID := uint(c.MustGet("user_id").(float64))
+	if !services.HasPermission(liftedByID, c.GetString("role"), model.PermissionBanUsers, ban.CategoryID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to lift this ban"})
+		return
+	}
+
+	ban, err := services.LiftBan(ban, liftedByID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift ban"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ban)
+}
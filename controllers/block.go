@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BlockUser godoc
+// @Summary      Block a user
+// @Description  Blocks a user: they can no longer reply to or react to your posts, their posts are collapsed for you and their notifications and live updates stop. Blocking also removes their follow of you and replaces a mute.
+// @Tags         blocks
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  model.Block
+// @Failure      400  {object}  map[string]interface{}  "{"error": "you cannot block or mute yourself"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to block user"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/block [post]
+func BlockUser(c *gin.Context) {
+	setBlock(c, model.BlockLevelBlock, "Failed to block user")
+}
+
+// MuteUser godoc
+// @Summary      Mute a user
+// @Description  Mutes a user: their posts are collapsed for you and their notifications and live updates stop, but they can still interact with your posts. Muting replaces a block.
+// @Tags         blocks
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  model.Block
+// @Failure      400  {object}  map[string]interface{}  "{"error": "you cannot block or mute yourself"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to mute user"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/mute [post]
+func MuteUser(c *gin.Context) {
+	setBlock(c, model.BlockLevelMute, "Failed to mute user")
+}
+
+func setBlock(c *gin.Context, level, failure string) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	block, err := services.SetBlock(userID, uint(targetID), level)
+	if errors.Is(err, services.ErrBlockSelf) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return
+	}
+
+	c.JSON(http.StatusOK, block)
+}
+
+// UnblockUser godoc
+// @Summary      Unblock a user
+// @Description  Lifts a block
+// @Tags         blocks
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "User unblocked successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User is not blocked"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to unblock user"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/block [delete]
+func UnblockUser(c *gin.Context) {
+	removeBlock(c, model.BlockLevelBlock, "User is not blocked", "Failed to unblock user", "User unblocked successfully")
+}
+
+// UnmuteUser godoc
+// @Summary      Unmute a user
+// @Description  Lifts a mute
+// @Tags         blocks
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "User unmuted successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User is not muted"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to unmute user"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/mute [delete]
+func UnmuteUser(c *gin.Context) {
+	removeBlock(c, model.BlockLevelMute, "User is not muted", "Failed to unmute user", "User unmuted successfully")
+}
+
+func removeBlock(c *gin.Context, level, notFound, failure, message string) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+
+	err = services.RemoveBlock(userID, uint(targetID), level)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// ListBlocks godoc
+// @Summary      List blocked and muted users
+// @Description  Lists the users the current user blocked or muted, optionally only one level
+// @Tags         blocks
+// @Produce      json
+// @Param        level  query     string  false  "block or mute"
+// @Success      200  {array}   model.Block
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve blocks"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/blocks [get]
+func ListBlocks(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	query := database.Database.Where("user_id = ?", userID)
+	if level := c.Query("level"); level != "" {
+		query = query.Where("level = ?", level)
+	}
+
+	var blocks []model.Block
+	if err := query.Preload("Target").Order("created_at DESC").Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, blocks)
+}
+
+// hiddenAuthors returns the users the signed-in viewer muted or blocked, whose
+// posts are collapsed for them. It is empty for anonymous viewers.
+func hiddenAuthors(c *gin.Context) map[uint]bool {
+	value, ok := c.Get("user_id")
+	if !ok {
+		return nil
+	}
+
+	hidden, err := services.HiddenAuthors(uint(value.(float64)))
+	if err != nil {
+		return nil
+	}
+	return hidden
+}
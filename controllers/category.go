@@ -11,9 +11,11 @@ import (
 
 // CreateCategoryRequest represents the payload required to create a category.
 type CreateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	ImageURL    string `json:"image_url"`
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description" binding:"required"`
+	ImageURL         string `json:"image_url"`
+	RequireChallenge bool   `json:"require_challenge"`
+	NSFW             bool   `json:"nsfw"`
 }
 
 // ListCategories godoc
@@ -72,7 +74,7 @@ func GetCategory(c *gin.Context) {
 
 // CreateCategory godoc
 // @Summary      Create a new category
-// @Description  Creates a new category with the provided name, description, and optional image URL
+// @Description  Creates a new category with the provided name, description, and optional image URL. Set require_challenge to ask for a proof-of-work challenge on new posts in the category.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
@@ -91,9 +93,11 @@ func CreateCategory(c *gin.Context) {
 	}
 
 	category := model.Category{
-		Name:        payload.Name,
-		Description: payload.Description,
-		ImageURL:    &payload.ImageURL,
+		Name:             payload.Name,
+		Description:      payload.Description,
+		ImageURL:         &payload.ImageURL,
+		RequireChallenge: payload.RequireChallenge,
+		NSFW:             payload.NSFW,
 	}
 
 	if result := database.Database.Create(&category); result.Error != nil {
@@ -106,9 +110,11 @@ func CreateCategory(c *gin.Context) {
 
 // UpdateCategoryRequest represents the full update payload for a category.
 type UpdateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	ImageURL    string `json:"image_url"`
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description" binding:"required"`
+	ImageURL         string `json:"image_url"`
+	RequireChallenge bool   `json:"require_challenge"`
+	NSFW             bool   `json:"nsfw"`
 }
 
 // UpdateCategory godoc
@@ -142,6 +148,8 @@ func UpdateCategory(c *gin.Context) {
 	category.Name = payload.Name
 	category.Description = payload.Description
 	category.ImageURL = &payload.ImageURL
+	category.RequireChallenge = payload.RequireChallenge
+	category.NSFW = payload.NSFW
 
 	if err := database.Database.Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChallenge godoc
+// @Summary      Get a proof-of-work challenge
+// @Description  Issues a signed challenge for an action (`register` or `post`). Find a solution string such that the SHA-256 hash of challenge + solution starts with `difficulty` zero bits, then send the challenge and solution in the X-Challenge and X-Challenge-Solution headers of the request. Each challenge works once and expires after a few minutes; the difficulty rises while many challenges are requested. `required` tells whether the action currently needs one; categories can require it for posts on their own.
+// @Tags         challenge
+// @Produce      json
+// @Param        action  query     string  true  "register or post"
+// @Success      200  {object}  map[string]interface{}  "{"challenge": "...", "action": "post", "difficulty": 18, "algorithm": "sha256", "expires_at": "...", "required": true}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "unknown challenge action"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to issue challenge"}"
+// @Router       /challenge [get]
+func GetChallenge(c *gin.Context) {
+	action := c.Query("action")
+
+	challenge, err := services.IssueChallenge(action)
+	if errors.Is(err, services.ErrUnknownChallengeAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge":  challenge.Challenge,
+		"action":     challenge.Action,
+		"difficulty": challenge.Difficulty,
+		"algorithm":  challenge.Algorithm,
+		"expires_at": challenge.ExpiresAt,
+		"required":   services.ChallengeRequired(action),
+	})
+}
+
+// challengeFailed enforces a challenge the route middleware did not already
+// check, such as one required by a category, and responds when it fails.
+func challengeFailed(c *gin.Context, action string) bool {
+	if c.GetBool("challenge_passed") {
+		return false
+	}
+
+	userID := uint(c.MustGet("user_id").(float64))
+	err := services.CheckChallenge(action, &userID, c.GetString("role"), c.GetHeader(services.ChallengeHeader), c.GetHeader(services.ChallengeSolutionHeader))
+	if err == nil {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "challenge_required": true, "action": action})
+	return true
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/services"
+	"onichan/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StartConversationRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required"`
+	Title   string `json:"title"`
+	Content string `json:"content" binding:"required"`
+}
+
+type SendMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// conversationFailed responds with the error from a conversation service
+// call.
+func conversationFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNotParticipant):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, services.ErrMessagingBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMessageRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRecipientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrNoRecipients),
+		errors.Is(err, services.ErrTooManyParticipants),
+		errors.Is(err, services.ErrEmptyMessage),
+		errors.Is(err, services.ErrConversationTitleSize),
+		errors.Is(err, services.ErrMessageTooLong),
+		errors.Is(err, utils.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// StartConversation godoc
+// @Summary      Start a private conversation
+// @Description  Sends a private message to one or more users, up to 10 people including the sender. Messaging a single user without a title continues the existing conversation with them. Users who blocked the sender cannot be messaged. Messages are at most 5000 characters, and a user can send at most 20 messages a minute.
+// @Tags         conversations
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      StartConversationRequest  true  "Recipients and first message"
+// @Success      201  {object}  map[string]interface{}  "{"conversation": {...}, "message": {...}}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "too many users in this conversation"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "you cannot message this user"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "you are sending messages too quickly, please wait a moment"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to start conversation"}"
+// @Security     ApiKeyAuth
+// @Router       /conversations [post]
+func StartConversation(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload StartConversationRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, message, err := services.StartConversation(userID, payload.UserIDs, trimmedOrNil(payload.Title), strings.TrimSpace(payload.Content))
+	if err != nil {
+		conversationFailed(c, err, "Failed to start conversation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"conversation": conversation,
+		"message":      message,
+	})
+}
+
+// ListConversations godoc
+// @Summary      List the current user's conversations
+// @Description  Returns the user's private conversations with the most recent activity first, each with its participants and number of unread messages, and the total number of unread messages.
+// @Tags         conversations
+// @Produce      json
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"conversations": [...], "unread": X, "total_pages": X}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve conversations"}"
+// @Security     ApiKeyAuth
+// @Router       /conversations [get]
+func ListConversations(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	conversations, count, err := services.ListConversations(userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations"})
+		return
+	}
+
+	unread, err := services.CountUnreadMessages(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+		"unread":        unread,
+		"total_pages":   (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// GetMessages godoc
+// @Summary      Get the messages of a conversation
+// @Description  Returns the conversation and its messages, newest first. Pass next_cursor from the previous response as cursor to get older messages; it is empty on the last page.
+// @Tags         conversations
+// @Produce      json
+// @Param        id      path      int     true   "Conversation ID"
+// @Param        cursor  query     string  false  "Cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}  "{"conversation": {...}, "messages": [...], "next_cursor": "..."}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid cursor"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Conversation not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve messages"}"
+// @Security     ApiKeyAuth
+// @Router       /conversations/{id}/messages [get]
+func GetMessages(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	conversationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	conversation, err := services.GetConversation(uint(conversationID), userID)
+	if err != nil {
+		conversationFailed(c, err, "Failed to retrieve messages")
+		return
+	}
+
+	messages, next, err := services.GetMessages(conversation.ID, userID, c.Query("cursor"), pageSize)
+	if err != nil {
+		conversationFailed(c, err, "Failed to retrieve messages")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversation": conversation,
+		"messages":     messages,
+		"next_cursor":  next,
+	})
+}
+
+// SendMessage godoc
+// @Summary      Send a message to a conversation
+// @Description  Adds a message to a conversation the user is part of and delivers it to the other participants over the websocket as a `message` event. Users cannot write to a conversation where a participant blocked them. Messages are at most 5000 characters, and a user can send at most 20 messages a minute.
+// @Tags         conversations
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                 true  "Conversation ID"
+// @Param        payload  body      SendMessageRequest  true  "Message"
+// @Success      201  {object}  model.Message
+// @Failure      400  {object}  map[string]interface{}  "{"error": "message cannot be empty"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "you cannot message this user"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Conversation not found"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "you are sending messages too quickly, please wait a moment"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to send message"}"
+// @Security     ApiKeyAuth
+// @Router       /conversations/{id}/messages [post]
+func SendMessage(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	conversationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	var payload SendMessageRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := services.SendMessage(uint(conversationID), userID, strings.TrimSpace(payload.Content))
+	if err != nil {
+		conversationFailed(c, err, "Failed to send message")
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// MarkConversationRead godoc
+// @Summary      Mark a conversation as read
+// @Description  Marks every message in the conversation as read by the current user.
+// @Tags         conversations
+// @Produce      json
+// @Param        id  path  int  true  "Conversation ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Conversation marked as read"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Conversation not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to mark conversation as read"}"
+// @Security     ApiKeyAuth
+// @Router       /conversations/{id}/read [post]
+func MarkConversationRead(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	conversationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	if err := services.MarkConversationRead(uint(conversationID), userID); err != nil {
+		conversationFailed(c, err, "Failed to mark conversation as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+}
@@ -3,7 +3,7 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"os"
+	"onichan/utils"
 	"path/filepath"
 	"time"
 
@@ -34,13 +34,8 @@ func UploadImage(c *gin.Context) {
 		return
 	}
 
-	uploadPath := os.Getenv("UPLOAD_PATH")
-	if uploadPath == "" {
-		uploadPath = "uploads"
-	}
-
 	uniqueFilename := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	dst := filepath.Join(uploadPath, uniqueFilename)
+	dst := filepath.Join(utils.UploadPath(), uniqueFilename)
 	if err := c.SaveUploadedFile(file, dst); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to save file"})
 		return
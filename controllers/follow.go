@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"onichan/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FollowUserRequest struct {
+	Notify bool `json:"notify"`
+}
+
+// FollowUser godoc
+// @Summary      Follow a user
+// @Description  Follows a user so their threads and replies show up in the feed. With notify set, a notification is sent whenever they start a thread. Following again updates notify.
+// @Tags         follows
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true   "User ID"
+// @Param        payload  body      FollowUserRequest  false  "Notification preference"
+// @Success      200  {object}  model.Follow
+// @Failure      400  {object}  map[string]interface{}  "{"error": "you cannot follow yourself"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to follow user"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/follow [post]
+func FollowUser(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload FollowUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	followingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	follow, err := services.FollowUser(userID, uint(followingID), payload.Notify)
+	if errors.Is(err, services.ErrFollowSelf) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, follow)
+}
+
+// UnfollowUser godoc
+// @Summary      Unfollow a user
+// @Description  Stops following a user and their thread notifications
+// @Tags         follows
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "User unfollowed successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "You are not following this user"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to unfollow"}"
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/follow [delete]
+func UnfollowUser(c *gin.Context) {
+	unfollow(c, "following_id", "You are not following this user", "User unfollowed successfully")
+}
+
+// FollowCategory godoc
+// @Summary      Follow a category
+// @Description  Follows a category so its new threads and replies show up in the feed
+// @Tags         follows
+// @Produce      json
+// @Param        id  path      int  true  "Category ID"
+// @Success      200  {object}  model.Follow
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Category not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to follow category"}"
+// @Security     ApiKeyAuth
+// @Router       /categories/{id}/follow [post]
+func FollowCategory(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	follow, err := services.FollowCategory(userID, uint(categoryID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, follow)
+}
+
+// UnfollowCategory godoc
+// @Summary      Unfollow a category
+// @Description  Stops following a category
+// @Tags         follows
+// @Produce      json
+// @Param        id  path      int  true  "Category ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Category unfollowed successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "You are not following this category"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to unfollow"}"
+// @Security     ApiKeyAuth
+// @Router       /categories/{id}/follow [delete]
+func UnfollowCategory(c *gin.Context) {
+	unfollow(c, "category_id", "You are not following this category", "Category unfollowed successfully")
+}
+
+func unfollow(c *gin.Context, column, notFound, message string) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+
+	err = services.Unfollow(userID, column, uint(targetID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// ListFollowers godoc
+// @Summary      List a user's followers
+// @Description  Lists the public profiles of the users following the user, most recent first
+// @Tags         follows
+// @Produce      json
+// @Param        id    path      int  true   "User ID"
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"users": [...], "total_pages": X}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve followers"}"
+// @Router       /users/{id}/followers [get]
+func ListFollowers(c *gin.Context) {
+	user, ok := findPublicUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.Follow{}).Where("following_id = ?", user.ID)
+
+	var follows []model.Follow
+	if err := query.Session(&gorm.Session{}).
+		Preload("Follower").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve followers"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve followers"})
+		return
+	}
+
+	users := make([]model.PublicUser, 0, len(follows))
+	for _, follow := range follows {
+		if follow.Follower != nil {
+			users = append(users, follow.Follower.Public())
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       users,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// ListFollowing godoc
+// @Summary      List what a user follows
+// @Description  Lists the users and categories the user follows, most recent first
+// @Tags         follows
+// @Produce      json
+// @Param        id    path      int  true   "User ID"
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"follows": [...], "total_pages": X}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve follows"}"
+// @Router       /users/{id}/following [get]
+func ListFollowing(c *gin.Context) {
+	user, ok := findPublicUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.Follow{}).Where("follower_id = ?", user.ID)
+
+	var follows []model.Follow
+	if err := query.Session(&gorm.Session{}).
+		Preload("Following").
+		Preload("Category").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve follows"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve follows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"follows":     follows,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// GetFeed godoc
+// @Summary      Get the home feed
+// @Description  Returns new threads and replies from followed users and categories, newest first, leaving out muted categories and, if the user hides NSFW content, NSFW categories. Pass next_cursor from the previous response as cursor to get the next page; it is empty on the last page.
+// @Tags         follows
+// @Produce      json
+// @Param        cursor  query     string  false  "Cursor from the previous page"
+// @Success      200  {object}  map[string]interface{}  "{"posts": [...], "next_cursor": "..."}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid cursor"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve feed"}"
+// @Security     ApiKeyAuth
+// @Router       /feed [get]
+func GetFeed(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	posts, next, err := services.GetFeed(userID, c.Query("cursor"), viewerPageSize(c))
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feed"})
+		return
+	}
+
+	hidden := hiddenAuthors(c)
+	for i := range posts {
+		posts[i].Collapsed = hidden[posts[i].UserID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":       posts,
+		"next_cursor": next,
+	})
+}
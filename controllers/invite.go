@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateInviteRequest struct {
+	MaxUses       int     `json:"max_uses"`
+	ExpiresInDays int     `json:"expires_in_days"`
+	Email         *string `json:"email"`
+}
+
+type RejectRegistrationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateInvite godoc
+// @Summary      Create an invite
+// @Description  Creates an invite code for registering while registration is invite-only. The code is only returned once. Staff with the manage_registrations permission can always invite; other users need a verified email and an account at least invite_min_account_days old, and their invites are limited to 10 uses, 30 days and 5 active invites. max_uses defaults to 1. An email locks the invite to that address.
+// @Tags         invites
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      CreateInviteRequest  true  "Invite"
+// @Success      201  {object}  map[string]interface{}  "{"code": "...", "invite": {...}}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "max_uses must be positive"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "you are not allowed to create invites yet"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to create invite"}"
+// @Security     ApiKeyAuth
+// @Router       /invites [post]
+func CreateInvite(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload CreateInviteRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payload.MaxUses == 0 {
+		payload.MaxUses = 1
+	}
+	if payload.MaxUses < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_uses must be positive"})
+		return
+	}
+	if payload.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be positive"})
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	invite, code, err := services.CreateInvite(user, payload.MaxUses, time.Duration(payload.ExpiresInDays)*24*time.Hour, payload.Email)
+	if errors.Is(err, services.ErrInviteNotAllowed) || errors.Is(err, services.ErrInviteLimit) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":   code,
+		"invite": invite,
+	})
+}
+
+// ListInvites godoc
+// @Summary      List invites
+// @Description  Lists the invites created by the current user. Staff with the manage_registrations permission can pass all=true to list everyone's invites.
+// @Tags         invites
+// @Produce      json
+// @Param        all   query     bool  false  "List all users' invites"
+// @Param        page  query     int   false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"invites": [...], "total_pages": X}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve invites"}"
+// @Security     ApiKeyAuth
+// @Router       /invites [get]
+func ListInvites(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.Invite{})
+	if c.Query("all") != "true" || !services.HasPermission(userID, c.GetString("role"), model.PermissionManageRegistrations, nil) {
+		query = query.Where("created_by_id = ?", userID)
+	}
+
+	var invites []model.Invite
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&invites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invites"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invites":     invites,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// RevokeInvite godoc
+// @Summary      Revoke an invite
+// @Description  Revokes an invite so it can no longer be used. Users can revoke their own invites; staff with the manage_registrations permission can revoke any invite.
+// @Tags         invites
+// @Produce      json
+// @Param        id  path      int  true  "Invite ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Invite revoked successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Invite not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to revoke invite"}"
+// @Security     ApiKeyAuth
+// @Router       /invites/{id} [delete]
+func RevokeInvite(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var invite model.Invite
+	if err := database.Database.First(&invite, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+
+	if invite.CreatedByID != userID && !services.HasPermission(userID, c.GetString("role"), model.PermissionManageRegistrations, nil) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+
+	if err := services.RevokeInvite(invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked successfully"})
+}
+
+// ListPendingRegistrations godoc
+// @Summary      List registrations awaiting approval
+// @Description  Returns the accounts registered while registration_mode is `approval`, oldest first
+// @Tags         admin
+// @Produce      json
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"users": [...], "total_pages": X}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve registrations"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/registrations [get]
+func ListPendingRegistrations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.User{}).Where("status = ?", model.UserStatusPending)
+
+	var users []model.User
+	if err := query.Session(&gorm.Session{}).
+		Order("created_at").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+		return
+	}
+
+	accounts := make([]model.Account, len(users))
+	for i, user := range users {
+		accounts[i] = user.Account()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       accounts,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
+}
+
+// ApproveRegistration godoc
+// @Summary      Approve a registration
+// @Description  Activates a pending account and emails the user that they can sign in
+// @Tags         admin
+// @Produce      json
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Registration approved"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Registration not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to approve registration"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/registrations/{id}/approve [post]
+func ApproveRegistration(c *gin.Context) {
+	user, ok := pendingRegistration(c)
+	if !ok {
+		return
+	}
+
+	if err := services.ApproveRegistration(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration approved"})
+}
+
+// RejectRegistration godoc
+// @Summary      Reject a registration
+// @Description  Deletes a pending account, freeing its username and email, and emails the user the optional reason
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true   "User ID"
+// @Param        payload  body      RejectRegistrationRequest  false  "Reason"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Registration rejected"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Registration not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to reject registration"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/registrations/{id}/reject [post]
+func RejectRegistration(c *gin.Context) {
+	var payload RejectRegistrationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, ok := pendingRegistration(c)
+	if !ok {
+		return
+	}
+
+	if err := services.RejectRegistration(user, payload.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration rejected"})
+}
+
+func pendingRegistration(c *gin.Context) (model.User, bool) {
+	var user model.User
+	if err := database.Database.First(&user, "id = ? AND status = ?", c.Param("id"), model.UserStatusPending).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return user, false
+	}
+	return user, true
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"net/http"
+	"onichan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS godoc
+// @Summary      Get the token verification keys
+// @Description  Returns the public keys tokens are signed with as a JSON Web Key Set, so that other services can verify them. Tokens name their key in the `kid` header.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  utils.JWKS
+// @Router       /.well-known/jwks.json [get]
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.PublicJWKS())
+}
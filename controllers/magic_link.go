@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"onichan/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const magicLinkNonceCookie = "magic_link_nonce"
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RequestMagicLink godoc
+// @Summary      Request a sign-in link
+// @Description  Emails a one-time sign-in link if an account uses this email. The response is the same either way. The link only works in the browser that requested it, which receives an HttpOnly nonce cookie.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      MagicLinkRequest  true  "Email"
+// @Success      200  {object}  map[string]interface{}  "{"message": "If an account uses this email, a sign-in link has been sent"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Magic link sign-in is disabled"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "Too many attempts, please try again later"}"
+// @Router       /auth/magic-link [post]
+func RequestMagicLink(c *gin.Context) {
+	if !services.MAGIC_LINK_ENABLED {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Magic link sign-in is disabled"})
+		return
+	}
+
+	var payload MagicLinkRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	emailKey := "magic:email:" + strings.ToLower(payload.Email)
+	ipKey := "magic:ip:" + c.ClientIP()
+	if throttled(c, emailKey, ipKey) {
+		return
+	}
+
+	// Every request counts, so the link mail cannot be used to flood an inbox.
+	recordFailure(nil, emailKey, ipKey)
+
+	nonce := utils.GenerateSecureToken(32)
+	http.SetCookie(c.Writer, utils.NewCookie(magicLinkNonceCookie, nonce, "/api/auth/magic-link", services.MAGIC_LINK_TTL*60, true))
+
+	var user model.User
+	if err := database.Database.First(&user, "email = ?", payload.Email).Error; err == nil {
+		go func() {
+			if err := services.SendMagicLink(user, nonce); err != nil {
+				fmt.Println(err)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account uses this email, a sign-in link has been sent"})
+}
+
+// ConsumeMagicLink godoc
+// @Summary      Sign in with a magic link
+// @Description  Exchanges the token from a sign-in link for the same response as Login: a session, or a two-factor challenge. Must be called from the browser that requested the link.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      ConsumeMagicLinkRequest  true  "Token from the link"
+// @Success      200  {object}  services.TokenPair
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid or expired link"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Magic link sign-in is disabled"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "Too many attempts, please try again later"}"
+// @Router       /auth/magic-link/consume [post]
+func ConsumeMagicLink(c *gin.Context) {
+	if !services.MAGIC_LINK_ENABLED {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Magic link sign-in is disabled"})
+		return
+	}
+
+	var payload ConsumeMagicLinkRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ipKey := "magic:ip:" + c.ClientIP()
+	if throttled(c, ipKey) {
+		return
+	}
+
+	nonce, _ := c.Cookie(magicLinkNonceCookie)
+	user, err := services.ConsumeMagicLink(payload.Token, nonce)
+	if errors.Is(err, services.ErrInvalidUserToken) {
+		recordFailure(nil, ipKey)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired link. Open it in the browser you requested it from"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify link"})
+		return
+	}
+
+	http.SetCookie(c.Writer, utils.NewCookie(magicLinkNonceCookie, "", "/api/auth/magic-link", -1, true))
+
+	completeLogin(c, user)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +23,7 @@ func GetUnreadNotifications(c *gin.Context) {
 	var notifications []model.Notification
 
 	userID := uint(c.MustGet("user_id").(float64))
+	pageSize := viewerPageSize(c)
 
 	if err := database.Database.
 		Preload("Post").
@@ -68,6 +70,7 @@ func ReadNotifications(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
 		return
 	}
+	services.ClearNotificationEmails(userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
 }
@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"onichan/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+// oauthBindingCookie ties an authorization request to the browser that
+// started it, so nobody can complete a flow in another browser.
+const oauthBindingCookie = "oauth_binding"
+
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type OAuthRegisterRequest struct {
+	RegistrationToken string `json:"registration_token" binding:"required"`
+	Username          string `json:"username" binding:"required"`
+	Email             string `json:"email"`
+	InviteCode        string `json:"invite_code"`
+}
+
+// ListIdentityProviders godoc
+// @Summary      List external identity providers
+// @Description  Returns the names of the configured external identity providers
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   string
+// @Router       /auth/oauth/providers [get]
+func ListIdentityProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListIdentityProviders())
+}
+
+// OAuthAuthorize godoc
+// @Summary      Start signing in with an external provider
+// @Description  Returns the provider's authorization URL to redirect the browser to. The provider redirects back to the frontend with `code` and `state`, which are then posted to the callback endpoint from the same browser, which receives an HttpOnly cookie binding it to the flow.
+// @Tags         auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Success      200  {object}  map[string]interface{}  "{"authorization_url": "https://..."}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "unknown identity provider"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to contact identity provider"}"
+// @Router       /auth/oauth/{provider}/authorize [get]
+func OAuthAuthorize(c *gin.Context) {
+	beginExternalLogin(c, nil)
+}
+
+// LinkIdentity godoc
+// @Summary      Link an external provider to the current account
+// @Description  Returns the provider's authorization URL. Completing the flow through the callback endpoint, from the same browser and signed in as the same user, links the provider account to the current user.
+// @Tags         auth
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Success      200  {object}  map[string]interface{}  "{"authorization_url": "https://..."}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "unknown identity provider"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to contact identity provider"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/oauth/{provider}/link [post]
+func LinkIdentity(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	beginExternalLogin(c, &userID)
+}
+
+func beginExternalLogin(c *gin.Context, linkUserID *uint) {
+	binding := utils.GenerateSecureToken(32)
+	authorizationURL, err := services.BeginExternalLogin(c.Param("provider"), linkUserID, binding)
+	if errors.Is(err, services.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to contact identity provider"})
+		return
+	}
+
+	http.SetCookie(c.Writer, utils.NewCookie(oauthBindingCookie, binding, "/api/auth/oauth", 10*60, true))
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authorizationURL})
+}
+
+// OAuthCallback godoc
+// @Summary      Complete an external sign-in
+// @Description  Redeems the authorization code. It has to come from the browser that started the flow. Returns a session (or two-factor challenge) for known accounts, confirms linking for linking flows, which need the user that started them to be signed in, and otherwise a registration token to pick a username with.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string                true  "Provider name"
+// @Param        payload   body      OAuthCallbackRequest  true  "Code and state from the provider redirect"
+// @Success      200  {object}  map[string]interface{}  "{"registration_required": true, "registration_token": "...", "suggested_username": "..."}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid or expired state"}"
+// @Failure      401  {object}  map[string]interface{}  "{"error": "Failed to verify identity"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "Sign in as the user that started linking"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "this account is already linked to another user"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Internal server error"}"
+// @Router       /auth/oauth/{provider}/callback [post]
+func OAuthCallback(c *gin.Context) {
+	var payload OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	binding, _ := c.Cookie(oauthBindingCookie)
+	identity, linkUserID, err := services.CompleteExternalLogin(c.Request.Context(), c.Param("provider"), payload.Code, payload.State, binding)
+	if errors.Is(err, services.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrInvalidOAuthState) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to verify identity"})
+		return
+	}
+
+	http.SetCookie(c.Writer, utils.NewCookie(oauthBindingCookie, "", "/api/auth/oauth", -1, true))
+
+	if linkUserID != nil {
+		userID, ok := c.Get("user_id")
+		if !ok || uint(userID.(float64)) != *linkUserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sign in as the user that started linking"})
+			return
+		}
+
+		if err := services.LinkIdentity(*linkUserID, identity); errors.Is(err, services.ErrIdentityAlreadyLinked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Account linked successfully"})
+		return
+	}
+
+	user, err := services.FindIdentityUser(identity)
+	if err == nil {
+		completeLogin(c, user)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	registrationToken, err := utils.GeneratePurposeToken(utils.AudienceOAuthRegistration, jwt.MapClaims{
+		"provider":       identity.Provider,
+		"subject":        identity.Subject,
+		"email":          identity.Email,
+		"email_verified": identity.EmailVerified,
+	}, 15*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	suggestedUsername := identity.PreferredUsername
+	if suggestedUsername == "" {
+		suggestedUsername, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration_required": true,
+		"registration_token":    registrationToken,
+		"suggested_username":    suggestedUsername,
+		"email":                 identity.Email,
+	})
+}
+
+// OAuthRegister godoc
+// @Summary      Finish registering with an external provider
+// @Description  Creates an account for a first-time external sign-in with the chosen username and starts a session, unless the registration needs approval. The email defaults to the one shared by the provider; an email the provider did not verify has to be verified as usual. Registration modes apply as for /auth/register. A registration token cannot be used again once its account exists.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      OAuthRegisterRequest  true  "Registration token and username"
+// @Success      200  {object}  services.TokenPair
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Email is required"}"
+// @Failure      401  {object}  map[string]interface{}  "{"error": "Invalid or expired registration token"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "an invite code is required to register"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "an account is already registered with this sign-in, sign in instead"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to create user"}"
+// @Router       /auth/oauth/register [post]
+func OAuthRegister(c *gin.Context) {
+	var payload OAuthRegisterRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := utils.ValidatePurposeToken(payload.RegistrationToken, utils.AudienceOAuthRegistration)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired registration token"})
+		return
+	}
+
+	identity := services.ExternalIdentity{}
+	identity.Provider, _ = claims["provider"].(string)
+	identity.Subject, _ = claims["subject"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if len(payload.Username) < 3 || len(payload.Username) > 31 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username must contain between 3 and 31 characters"})
+		return
+	}
+
+	email := payload.Email
+	if email == "" {
+		email = identity.Email
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	if _, err := services.FindIdentityUser(identity); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrIdentityRegistered.Error()})
+		return
+	}
+
+	var emailUser model.User
+	if result := database.Database.First(&emailUser, "email = ?", email); result.Error == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already in use. Sign in to that account and link the provider instead"})
+		return
+	}
+
+	user, err := services.CreateUserFromIdentity(identity, payload.Username, email, payload.InviteCode)
+	if errors.Is(err, services.ErrIdentityRegistered) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		registrationFailed(c, err)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := services.SendVerificationEmail(user); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	if user.Status == model.UserStatusPending {
+		c.JSON(http.StatusOK, gin.H{"message": "User created successfully. An admin has to approve your registration before you can sign in"})
+		return
+	}
+
+	startSession(c, user)
+}
+
+// ListIdentities godoc
+// @Summary      List linked external accounts
+// @Description  Returns the external identity provider accounts linked to the current user
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   model.Identity
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve identities"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/identities [get]
+func ListIdentities(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var identities []model.Identity
+	if err := database.Database.Where("user_id = ?", userID).Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentity godoc
+// @Summary      Unlink an external account
+// @Description  Removes a linked external account. The last way to sign in cannot be removed; set a password first.
+// @Tags         auth
+// @Produce      json
+// @Param        id  path      int  true  "Identity ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Account unlinked successfully"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Identity not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot unlink the only way to sign in to this account"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to unlink account"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/identities/{id} [delete]
+func UnlinkIdentity(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	identityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		return
+	}
+
+	err = services.UnlinkIdentity(userID, uint(identityID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		return
+	} else if errors.Is(err, services.ErrLastLoginMethod) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked successfully"})
+}
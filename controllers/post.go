@@ -6,7 +6,6 @@ import (
 	"onichan/database"
 	"onichan/model"
 	"onichan/services"
-	"onichan/websocket"
 	"os"
 	"strconv"
 	"time"
@@ -102,7 +101,7 @@ func validatePost(payload interface{}) (bool, string) {
 
 // CreatePost godoc
 // @Summary      Create a new post
-// @Description  Creates a master post or a reply. Master posts require a title and cannot have a parent, while replies must have a parent and must not have a title.
+// @Description  Creates a master post or a reply. Master posts require a title and cannot have a parent, while replies must have a parent and must not have a title. Starting threads and posting links need the trust levels set by trust_level_create_thread and trust_level_post_links. A solved proof-of-work challenge (see /challenge) is needed when the challenge_posts setting is on or the category requires one, unless the user is trusted.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -136,6 +135,28 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	if categoryBanned(c, userIDUint, payload.CategoryID) {
+		return
+	}
+
+	var category model.Category
+	if err := database.Database.First(&category, payload.CategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if category.RequireChallenge && challengeFailed(c, services.ChallengeActionPost) {
+		return
+	}
+
+	if payload.IsMasterPost && trustLevelTooLow(c, services.SettingTrustLevelCreateThread, "Your trust level is too low to start threads") {
+		return
+	}
+
+	if linksNotAllowed(c, payload.Title, payload.Content) {
+		return
+	}
+
 	post := model.Post{
 		UserID:       userIDUint,
 		User:         user,
@@ -153,6 +174,11 @@ func CreatePost(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Reply to post not found"})
 			return
 		}
+
+		if services.IsBlocked(replyToPost.UserID, userIDUint) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot reply to this user"})
+			return
+		}
 	}
 
 	if payload.ParentPostID != nil {
@@ -161,6 +187,11 @@ func CreatePost(c *gin.Context) {
 			return
 		}
 
+		if services.IsBlocked(parentPost.UserID, userIDUint) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot reply to this user"})
+			return
+		}
+
 		if payload.ReplyToID != nil {
 			if err := database.Database.First(&replyToPost, payload.ReplyToID).Error; err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Reply to post not found"})
@@ -181,7 +212,11 @@ func CreatePost(c *gin.Context) {
 	}
 
 	if payload.ParentPostID != nil {
-		websocket.SendNewPostSignal(*post.ParentPostID, userIDUint)
+		services.SignalNewPost(*post.ParentPostID, userIDUint)
+	}
+
+	if post.IsMasterPost {
+		go services.NotifyFollowers(post)
 	}
 
 	if payload.ReplyToID != nil && replyToPost.UserID != userIDUint {
@@ -208,6 +243,7 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	pageSize := viewerPageSize(c)
 	c.JSON(http.StatusOK, gin.H{
 		"page": (int(count) + pageSize + 1) / pageSize,
 		"id":   post.ID,
@@ -216,13 +252,14 @@ func CreatePost(c *gin.Context) {
 
 // ListPosts godoc
 // @Summary      List posts
-// @Description  Retrieves a paginated list of master posts from a category, identified by either category ID or category name.
+// @Description  Retrieves a paginated list of master posts from a category, identified by either category ID or category name. For signed-in users, posts by users they muted or blocked are marked as collapsed, and their page size and thread sort preferences apply. NSFW categories are refused for users who hide NSFW content.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
 // @Param        category_id    query     string  false  "Category ID"
 // @Param        category_name  query     string  false  "Category Name"
 // @Param        page           query     int     false  "Page number"  default(1)
+// @Param        sort           query     string  false  "activity, newest or oldest; defaults to the viewer's thread_sort preference"
 // @Success      200  {object}  map[string]interface{}  "List of posts and total_pages"
 // @Failure      400  {object}  map[string]interface{}  "Bad Request"
 // @Failure      404  {object}  map[string]interface{}  "Not Found"
@@ -234,26 +271,36 @@ func ListPosts(c *gin.Context) {
 	categoryID := c.Query("category_id")
 	categoryName := c.Query("category_name")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	preferences := viewerPreferences(c)
+	pageSize := viewerPageSize(c)
+	sort := c.DefaultQuery("sort", preferences.ThreadSort)
 
 	if categoryID == "" && categoryName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Category ID is required"})
 		return
 	}
 
+	var category model.Category
 	if categoryName != "" {
-		var category model.Category
 		if err := database.Database.Where("name = ?", categoryName).First(&category).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 			return
 		}
 		categoryID = strconv.Itoa(int(category.ID))
+	} else if err := database.Database.First(&category, "id = ?", categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	if nsfwHidden(c, category) {
+		return
 	}
 
 	offset := (page - 1) * pageSize
 
 	if err := database.Database.
 		Preload("User").
-		Order("last_updated DESC").
+		Order(services.ThreadOrder(sort)).
 		Where("category_id = ? AND is_master_post = ?", categoryID, true).
 		Offset(offset).
 		Limit(pageSize).
@@ -278,6 +325,11 @@ func ListPosts(c *gin.Context) {
 		posts[i].RepliesCount = int(replyCount)
 	}
 
+	hidden := hiddenAuthors(c)
+	for i := range posts {
+		posts[i].Collapsed = hidden[posts[i].UserID]
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"posts":       posts,
 		"total_pages": (int(totalPosts) + pageSize - 1) / pageSize,
@@ -326,7 +378,7 @@ func getPostReactions(postID uint, c *gin.Context) ([]model.PostReactionCount, [
 
 // GetPost godoc
 // @Summary      Get a post and its replies
-// @Description  Retrieves a specific post by its ID. Also returns any replies, category and user details, reaction data, etc. Pagination is applied to replies.
+// @Description  Retrieves a specific post by its ID. Also returns any replies, category and user details, reaction data, etc. Pagination is applied to replies using the viewer's page size. For signed-in users, posts by users they muted or blocked are marked as collapsed. Posts in NSFW categories are refused for users who hide NSFW content.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -351,6 +403,11 @@ func GetPost(c *gin.Context) {
 		return
 	}
 
+	if nsfwHidden(c, post.Category) {
+		return
+	}
+
+	pageSize := viewerPageSize(c)
 	offset := (page - 1) * pageSize
 
 	if err := database.Database.
@@ -380,6 +437,16 @@ func GetPost(c *gin.Context) {
 		Where("parent_post_id = ?", post.ID).
 		Count(&replyCount)
 
+	hidden := hiddenAuthors(c)
+	for i := range posts {
+		posts[i].Collapsed = hidden[posts[i].UserID]
+	}
+	post.Collapsed = hidden[post.UserID]
+
+	if userID, ok := c.Get("user_id"); ok {
+		go services.RecordThreadView(uint(userID.(float64)), post.ID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"posts":       posts,
 		"master_post": post,
@@ -389,7 +456,7 @@ func GetPost(c *gin.Context) {
 
 // UpdatePost godoc
 // @Summary      Update an existing post
-// @Description  Fully update an existing post by its ID. Respects master/reply post validation rules.
+// @Description  Fully update an existing post by its ID. Respects master/reply post validation rules. Moving another user's post to a different category needs edit_any_post in both categories.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -415,11 +482,15 @@ func UpdatePost(c *gin.Context) {
 	userIDUint := uint(userID.(float64))
 	role := c.GetString("role")
 
-	if post.UserID != userIDUint && role != "admin" {
+	if post.UserID != userIDUint && !services.HasPermission(userIDUint, role, model.PermissionEditAnyPost, &post.CategoryID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to update this post"})
 		return
 	}
 
+	if categoryBanned(c, userIDUint, post.CategoryID) {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -430,11 +501,26 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	// Moving someone else's post needs edit_any_post in the new category too
+	if payload.CategoryID != post.CategoryID && post.UserID != userIDUint &&
+		!services.HasPermission(userIDUint, role, model.PermissionEditAnyPost, &payload.CategoryID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to move this post to that category"})
+		return
+	}
+
+	if categoryBanned(c, userIDUint, payload.CategoryID) {
+		return
+	}
+
 	if ok, message := validatePost(payload); !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": message})
 		return
 	}
 
+	if linksNotAllowed(c, payload.Title, payload.Content) {
+		return
+	}
+
 	if post.ReplyToID != payload.ReplyToID && payload.ReplyToID != nil {
 		var replyToPost model.Post
 		if err := database.Database.First(&replyToPost, payload.ReplyToID).Error; err != nil {
@@ -467,7 +553,7 @@ func UpdatePost(c *gin.Context) {
 
 // PatchPost godoc
 // @Summary      Partially update an existing post
-// @Description  Updates only the fields provided in the request body. Must pass post ID via the path. Respects user ownership or admin rights.
+// @Description  Updates only the fields provided in the request body. Must pass post ID via the path. Respects user ownership or the edit_any_post permission.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -492,11 +578,15 @@ func PatchPost(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	role := c.GetString("role")
 
-	if post.UserID != uint(userID.(float64)) && role != "admin" {
+	if post.UserID != uint(userID.(float64)) && !services.HasPermission(uint(userID.(float64)), role, model.PermissionEditAnyPost, &post.CategoryID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to update this post"})
 		return
 	}
 
+	if categoryBanned(c, uint(userID.(float64)), post.CategoryID) {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -532,6 +622,13 @@ func PatchPost(c *gin.Context) {
 		return
 	}
 
+	// Only the fields being changed are checked for links
+	title, _ := payload["title"].(string)
+	content, _ := payload["content"].(string)
+	if linksNotAllowed(c, &title, content) {
+		return
+	}
+
 	if err := database.Database.Save(&post).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
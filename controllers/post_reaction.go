@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,7 +17,7 @@ type ToggleReactionRequest struct {
 
 // ToggleReaction godoc
 // @Summary      Toggle a reaction on a post
-// @Description  Adds or removes a user's reaction on a post, depending on whether the user has already reacted.
+// @Description  Adds or removes a user's reaction on a post, depending on whether the user has already reacted. The reaction's weight is added to or taken from the author's reputation.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -24,6 +25,7 @@ type ToggleReactionRequest struct {
 // @Success      200    {object}  map[string]interface{}  "{"message": "Reaction added"} or {"message": "Reaction removed"}"
 // @Failure      400    {object}  map[string]interface{}  "{"error": "Bad request"}"
 // @Failure      401    {object}  map[string]interface{}  "{"error": "Unauthorized"}"
+// @Failure      403    {object}  map[string]interface{}  "{"error": "You cannot react to this user's posts"}"
 // @Failure      500    {object}  map[string]interface{}  "{"error": "Failed to add reaction" or "Failed to remove reaction"}"
 // @Security     ApiKeyAuth
 // @Router       /posts/reactions [put]
@@ -41,13 +43,23 @@ func ToggleReaction(c *gin.Context) {
 		return
 	}
 
+	var post model.Post
+	if err := database.Database.First(&post, payload.PostID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if categoryBanned(c, uint(userID.(float64)), post.CategoryID) {
+		return
+	}
+
 	var reaction model.PostReaction
 	err := database.Database.
 		Where("post_id = ? AND user_id = ? AND reaction_id = ?", payload.PostID, userID, payload.ReactionID).
 		First(&reaction).Error
 
 	if err == nil {
-		if err := database.Database.Unscoped().Delete(&reaction).Error; err != nil {
+		if err := services.RemoveReaction(reaction, post); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
 			return
 		}
@@ -58,13 +70,18 @@ func ToggleReaction(c *gin.Context) {
 		return
 	}
 
+	if services.IsBlocked(post.UserID, uint(userID.(float64))) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot react to this user's posts"})
+		return
+	}
+
 	newReaction := model.PostReaction{
 		PostID:     payload.PostID,
 		UserID:     uint(userID.(float64)),
 		ReactionID: payload.ReactionID,
 	}
 
-	if err := database.Database.Create(&newReaction).Error; err != nil {
+	if err := services.AddReaction(&newReaction, post); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reaction"})
 		return
 	}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/model"
+	"onichan/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdatePreferencesRequest struct {
+	PageSize              *int    `json:"page_size"`
+	ThreadSort            *string `json:"thread_sort"`
+	Timezone              *string `json:"timezone"`
+	Locale                *string `json:"locale"`
+	EmailOnReply          *bool   `json:"email_on_reply"`
+	EmailOnFollowedThread *bool   `json:"email_on_followed_thread"`
+	EmailOnMessage        *bool   `json:"email_on_message"`
+	HideNSFW              *bool   `json:"hide_nsfw"`
+	MutedCategoryIDs      []uint  `json:"muted_category_ids"`
+}
+
+// viewerPreferences returns the signed-in user's preferences, or the
+// defaults for anonymous requests. They are loaded once per request.
+func viewerPreferences(c *gin.Context) model.UserPreferences {
+	if preferences, ok := c.Get("preferences"); ok {
+		return preferences.(model.UserPreferences)
+	}
+
+	value, ok := c.Get("user_id")
+	if !ok {
+		return services.DefaultPreferences(0)
+	}
+
+	userID := uint(value.(float64))
+	preferences, err := services.GetPreferences(userID)
+	if err != nil {
+		preferences = services.DefaultPreferences(userID)
+	}
+	c.Set("preferences", preferences)
+	return preferences
+}
+
+// viewerPageSize returns the page size the viewer chose, or PAGE_SIZE.
+func viewerPageSize(c *gin.Context) int {
+	if size := viewerPreferences(c).PageSize; size != 0 {
+		return size
+	}
+	return pageSize
+}
+
+// nsfwHidden responds with an error when the category is NSFW and the viewer
+// hides NSFW content.
+func nsfwHidden(c *gin.Context, category model.Category) bool {
+	if !category.NSFW || !viewerPreferences(c).HideNSFW {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "This category is NSFW and you chose to hide NSFW content", "nsfw": true})
+	return true
+}
+
+// GetPreferences godoc
+// @Summary      Get the current user's preferences
+// @Description  Returns the user's preferences, or the defaults when they never saved any. A page_size of 0 means the forum's default page size.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  model.UserPreferences
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve preferences"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/preferences [get]
+func GetPreferences(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	preferences, err := services.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary      Update the current user's preferences
+// @Description  Updates the given preferences. page_size is between 5 and 100, or 0 for the forum's default, and applies to thread, reply, search, feed and notification lists. thread_sort is `activity`, `newest` or `oldest`. timezone is an IANA name such as Europe/Berlin. muted_category_ids must be existing categories; duplicates are dropped. Muted categories and, with hide_nsfw, NSFW categories are left out of search and the feed. Email notifications are sent while the user is offline, at most once per conversation or thread every NOTIFICATION_EMAIL_INTERVAL minutes until they read it.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      UpdatePreferencesRequest  true  "Preferences to change"
+// @Success      200  {object}  model.UserPreferences
+// @Failure      400  {object}  map[string]interface{}  "{"error": "unknown timezone"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update preferences"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me/preferences [patch]
+func UpdatePreferences(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences, err := services.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	if payload.PageSize != nil {
+		preferences.PageSize = *payload.PageSize
+	}
+	if payload.ThreadSort != nil {
+		preferences.ThreadSort = *payload.ThreadSort
+	}
+	if payload.Timezone != nil {
+		preferences.Timezone = strings.TrimSpace(*payload.Timezone)
+	}
+	if payload.Locale != nil {
+		preferences.Locale = strings.TrimSpace(*payload.Locale)
+	}
+	if payload.EmailOnReply != nil {
+		preferences.EmailOnReply = *payload.EmailOnReply
+	}
+	if payload.EmailOnFollowedThread != nil {
+		preferences.EmailOnFollowedThread = *payload.EmailOnFollowedThread
+	}
+	if payload.EmailOnMessage != nil {
+		preferences.EmailOnMessage = *payload.EmailOnMessage
+	}
+	if payload.HideNSFW != nil {
+		preferences.HideNSFW = *payload.HideNSFW
+	}
+	if payload.MutedCategoryIDs != nil {
+		preferences.MutedCategoryIDs = payload.MutedCategoryIDs
+	}
+
+	err = services.SavePreferences(&preferences)
+	if errors.Is(err, services.ErrInvalidPageSize) ||
+		errors.Is(err, services.ErrInvalidThreadSort) ||
+		errors.Is(err, services.ErrInvalidTimezone) ||
+		errors.Is(err, services.ErrInvalidLocale) ||
+		errors.Is(err, services.ErrInvalidCategories) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
@@ -47,13 +47,14 @@ func GetReaction(c *gin.Context) {
 }
 
 type CreateReactionRequest struct {
-	Name  string `json:"name" binding:"required"`
-	Emoji string `json:"emoji" binding:"required"`
+	Name   string `json:"name" binding:"required"`
+	Emoji  string `json:"emoji" binding:"required"`
+	Weight int    `json:"weight"`
 }
 
 // CreateReaction godoc
 // @Summary      Create a new reaction
-// @Description  Creates a new reaction with the specified name and emoji
+// @Description  Creates a new reaction with the specified name and emoji. Its weight is added to the reputation of the authors of posts that get it, e.g. 1 for "like" and -1 for "dislike".
 // @Tags         reactions
 // @Accept       json
 // @Produce      json
@@ -65,8 +66,9 @@ type CreateReactionRequest struct {
 // @Router       /reactions [post]
 func CreateReaction(c *gin.Context) {
 	var payload struct {
-		Name  string `json:"name" binding:"required"`
-		Emoji string `json:"emoji" binding:"required"`
+		Name   string `json:"name" binding:"required"`
+		Emoji  string `json:"emoji" binding:"required"`
+		Weight int    `json:"weight"`
 	}
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -75,8 +77,9 @@ func CreateReaction(c *gin.Context) {
 	}
 
 	reaction := model.Reaction{
-		Name:  payload.Name,
-		Emoji: payload.Emoji,
+		Name:   payload.Name,
+		Emoji:  payload.Emoji,
+		Weight: payload.Weight,
 	}
 
 	if result := database.Database.Create(&reaction); result.Error != nil {
@@ -89,7 +92,7 @@ func CreateReaction(c *gin.Context) {
 
 // UpdateReaction godoc
 // @Summary      Update an existing reaction (full update)
-// @Description  Updates all fields of a reaction by its ID
+// @Description  Updates all fields of a reaction by its ID. A new weight only applies to later reactions until reputation is recalculated with the recalculate_reputation script.
 // @Tags         reactions
 // @Accept       json
 // @Produce      json
@@ -124,7 +127,7 @@ func UpdateReaction(c *gin.Context) {
 
 // PatchReaction godoc
 // @Summary      Partially update an existing reaction
-// @Description  Updates only the fields provided in the request body
+// @Description  Updates only the fields provided in the request body. A new weight only applies to later reactions until reputation is recalculated with the recalculate_reputation script.
 // @Tags         reactions
 // @Accept       json
 // @Produce      json
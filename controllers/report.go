@@ -4,32 +4,33 @@ import (
 	"net/http"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateReportRequest struct {
-	PostID uint `json:"post_id" binding:"required"`
+	PostID    *uint `json:"post_id"`
+	MessageID *uint `json:"message_id"`
 }
 
 // CreateReport godoc
-// @Summary      Create a new report for a post
-// @Description  Creates a report for a specific post by ID
+// @Summary      Report a post or a private message
+// @Description  Creates a report for a post by post_id, or for a private message the user received by message_id. Exactly one of them must be set. Reports from users with the trust level set by trust_level_weighted_flags count double.
 // @Tags         reports
 // @Accept       json
 // @Produce      json
 // @Param        payload body      CreateReportRequest true "Report creation payload"
 // @Success      200     {object}  map[string]interface{}  "{"message": "Report created successfully"}"
-// @Failure      400     {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      400     {object}  map[string]interface{}  "{"error": "Either post_id or message_id is required"}"
 // @Failure      404     {object}  map[string]interface{}  "{"error": "Post not found"}"
 // @Failure      500     {object}  map[string]interface{}  "{"error": "Failed to create report"}"
 // @Security     ApiKeyAuth
 // @Router       /reports [post]
 func CreateReport(c *gin.Context) {
-	var payload struct {
-		PostID uint `json:"post_id" binding:"required"`
-	}
+	var payload CreateReportRequest
 	userID := uint(c.MustGet("user_id").(float64))
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -37,20 +38,38 @@ func CreateReport(c *gin.Context) {
 		return
 	}
 
-	var post model.Post
-	if err := database.Database.First(&post, payload.PostID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	if (payload.PostID == nil) == (payload.MessageID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either post_id or message_id is required"})
 		return
 	}
 
-	if post.IsDeleted {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post has been removed already"})
-		return
+	if payload.PostID != nil {
+		var post model.Post
+		if err := database.Database.First(&post, *payload.PostID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		if post.IsDeleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post has been removed already"})
+			return
+		}
+	} else {
+		var message model.Message
+		if err := database.Database.First(&message, *payload.MessageID).Error; err != nil || !services.CanReportMessage(message, userID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
 	}
 
 	report := model.Report{
-		PostID: payload.PostID,
-		UserID: userID,
+		PostID:    payload.PostID,
+		MessageID: payload.MessageID,
+		UserID:    userID,
+		Weight:    1,
+	}
+	if services.MeetsTrustLevel(userID, c.GetString("role"), c.GetInt("trust_level"), services.SettingTrustLevelWeightedFlags) {
+		report.Weight = 2
 	}
 
 	if result := database.Database.Create(&report); result.Error != nil {
@@ -65,10 +84,11 @@ func CreateReport(c *gin.Context) {
 
 // ListReports godoc
 // @Summary      List all reports
-// @Description  Returns a paginated list of reports, including the reported Post and the reporting User
+// @Description  Returns a paginated list of reports, including the reported Post or Message and the reporting User. Category moderators only see reports on posts in their categories; reports on private messages need the resolve_reports permission globally. Use sort=weight to list the most heavily flagged reports first.
 // @Tags         reports
 // @Produce      json
-// @Param        page  query     int  false  "Page number" default(1)
+// @Param        page  query     int     false  "Page number" default(1)
+// @Param        sort  query     string  false  "Sort order, `weight` or the default newest first"
 // @Success      200   {object}  map[string]interface{}  "{"reports": [...], "total_pages": X}"
 // @Failure      500   {object}  map[string]interface{}  "{"error": "Failed to retrieve reports"}"
 // @Security     ApiKeyAuth
@@ -76,23 +96,41 @@ func CreateReport(c *gin.Context) {
 func ListReports(c *gin.Context) {
 	var reports []model.Report
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	userID := uint(c.MustGet("user_id").(float64))
 
 	offset := (page - 1) * pageSize
 
-	if err := database.Database.
-		Order("created_at DESC").
+	query := database.Database.Model(&model.Report{})
+	if !services.HasPermission(userID, c.GetString("role"), model.PermissionResolveReports, nil) {
+		categoryIDs, err := services.PermissionCategories(userID, model.PermissionResolveReports)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+			return
+		}
+		query = query.Where("post_id IN (?)", database.Database.Model(&model.Post{}).Select("id").Where("category_id IN ?", categoryIDs))
+	}
+
+	order := "created_at DESC"
+	if c.Query("sort") == "weight" {
+		order = "weight DESC, created_at DESC"
+	}
+
+	if err := query.Session(&gorm.Session{}).
+		Order(order).
 		Offset(offset).
 		Limit(pageSize).
 		Preload("User").
 		Preload("Post").
 		Preload("Post.User").
+		Preload("Message").
+		Preload("Message.User").
 		Find(&reports).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
 		return
 	}
 
 	var count int64
-	if err := database.Database.Model(&model.Report{}).Count(&count).Error; err != nil {
+	if err := query.Count(&count).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get report count"})
 		return
 	}
@@ -104,28 +142,27 @@ func ListReports(c *gin.Context) {
 }
 
 type ResolveReportRequest struct {
-	ReportID   uint `json:"report_id" binding:"required"`
-	DeletePost bool `json:"delete_post"`
+	ReportID      uint `json:"report_id" binding:"required"`
+	DeletePost    bool `json:"delete_post"`
+	DeleteMessage bool `json:"delete_message"`
 }
 
 // ResolveReport godoc
 // @Summary      Resolve a report
-// @Description  Resolves a report by marking it as resolved. Optionally deletes the associated post if specified.
+// @Description  Resolves a report by marking it as resolved. Optionally deletes the associated post if specified, which needs the delete_any_post permission for the post's category and costs its author the removed_post_penalty in reputation. Reports on private messages need resolve_reports globally, and delete_message removes the message, which needs delete_any_post globally.
 // @Tags         reports
 // @Accept       json
 // @Produce      json
 // @Param        payload  body      ResolveReportRequest  true  "Resolve Report Request"
 // @Success      200      {object}  map[string]interface{}  "{"message": "Report resolved successfully"}"
 // @Failure      400      {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      403      {object}  map[string]interface{}  "{"error": "You are not allowed to resolve this report"}"
 // @Failure      404      {object}  map[string]interface{}  "{"error": "Report or post not found"}"
 // @Failure      500      {object}  map[string]interface{}  "{"error": "Failed to resolve report"}"
 // @Security     ApiKeyAuth
 // @Router       /reports [patch]
 func ResolveReport(c *gin.Context) {
-	var payload struct {
-		ReportID   uint `json:"report_id" binding:"required"`
-		DeletePost bool `json:"delete_post"`
-	}
+	var payload ResolveReportRequest
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -140,21 +177,64 @@ func ResolveReport(c *gin.Context) {
 		return
 	}
 
+	if report.MessageID != nil {
+		resolveMessageReport(c, report, payload.DeleteMessage)
+		return
+	}
+
+	if err := database.Database.First(&post, *report.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	userID := uint(c.MustGet("user_id").(float64))
+	role := c.GetString("role")
+
+	if !services.HasPermission(userID, role, model.PermissionResolveReports, &post.CategoryID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to resolve this report"})
+		return
+	}
+
 	if payload.DeletePost {
-		if err := database.Database.First(&post, report.PostID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		if !services.HasPermission(userID, role, model.PermissionDeleteAnyPost, &post.CategoryID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this post"})
+			return
+		}
+
+		if err := services.RemovePost(&post); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+	}
+
+	report.Resolved = true
+	if result := database.Database.Save(&report); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
 
-		post.Content = "[This post has been deleted by a moderator]"
-		post.IsDeleted = true
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved successfully"})
+}
 
-		if post.Title != nil {
-			*post.Title = "[This post has been deleted by a moderator]"
+// resolveMessageReport resolves a report on a private message, which only
+// global moderators may see.
+func resolveMessageReport(c *gin.Context, report model.Report, deleteMessage bool) {
+	userID := uint(c.MustGet("user_id").(float64))
+	role := c.GetString("role")
+
+	if !services.HasPermission(userID, role, model.PermissionResolveReports, nil) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to resolve this report"})
+		return
+	}
+
+	if deleteMessage {
+		if !services.HasPermission(userID, role, model.PermissionDeleteAnyPost, nil) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this message"})
+			return
 		}
 
-		if result := database.Database.Save(&post); result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		if err := database.Database.Delete(&model.Message{}, *report.MessageID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 	}
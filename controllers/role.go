@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	UserID     uint `json:"user_id" binding:"required"`
+	RoleID     uint `json:"role_id" binding:"required"`
+	CategoryID uint `json:"category_id" binding:"required"`
+}
+
+// ListPermissions godoc
+// @Summary      List permissions
+// @Description  Returns every permission that can be granted to roles, and the ones that can be limited to a category.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"permissions": [...], "category_permissions": [...]}"
+// @Security     ApiKeyAuth
+// @Router       /admin/permissions [get]
+func ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"permissions":          model.Permissions,
+		"category_permissions": model.CategoryPermissions,
+	})
+}
+
+// ListRoles godoc
+// @Summary      List roles
+// @Description  Returns every role with its permissions
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   model.Role
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve roles"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/roles [get]
+func ListRoles(c *gin.Context) {
+	roles, err := services.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole godoc
+// @Summary      Create a role
+// @Description  Creates a role with the given permissions
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      CreateRoleRequest  true  "Role"
+// @Success      201  {object}  model.Role
+// @Failure      400  {object}  map[string]interface{}  "{"error": "unknown permission"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot grant permissions you do not hold"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "Role already exists"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to create role"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/roles [post]
+func CreateRole(c *gin.Context) {
+	var payload CreateRoleRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(payload.Name) > 31 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must contain at most 31 characters"})
+		return
+	}
+
+	if payload.Permissions == nil {
+		payload.Permissions = []string{}
+	}
+
+	role, err := services.CreateRole(model.Role{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	}, uint(c.MustGet("user_id").(float64)), c.GetString("role"))
+	if errors.Is(err, services.ErrUnknownPermission) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrPermissionNotHeld) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole godoc
+// @Summary      Update a role
+// @Description  Updates the given fields of a role. System roles cannot be renamed and the admin role's permissions cannot be changed. Changing permissions requires holding every old and new permission of the role and outranking everyone who holds it.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true  "Role ID"
+// @Param        payload  body      UpdateRoleRequest  true  "Fields to update"
+// @Success      200  {object}  model.Role
+// @Failure      400  {object}  map[string]interface{}  "{"error": "unknown permission"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Role not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "system roles cannot be renamed or deleted, and admin keeps every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update role"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/roles/{id} [patch]
+func UpdateRole(c *gin.Context) {
+	var role model.Role
+	if err := database.Database.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+
+	var payload UpdateRoleRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if payload.Name != nil && (len(*payload.Name) == 0 || len(*payload.Name) > 31) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must contain between 1 and 31 characters"})
+		return
+	}
+
+	role, err := services.UpdateRole(role, payload.Name, payload.Description, payload.Permissions, uint(c.MustGet("user_id").(float64)), c.GetString("role"))
+	if errors.Is(err, services.ErrUnknownPermission) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrPermissionNotHeld) || errors.Is(err, services.ErrTargetMorePrivileged) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrSystemRole) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole godoc
+// @Summary      Delete a role
+// @Description  Deletes a role and its category assignments. System roles and roles that are still some user's global role cannot be deleted. Only users holding every permission of the role can delete it.
+// @Tags         admin
+// @Produce      json
+// @Param        id  path      int  true  "Role ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Role deleted successfully"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot grant permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Role not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "role is still assigned to users"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to delete role"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/roles/{id} [delete]
+func DeleteRole(c *gin.Context) {
+	var role model.Role
+	if err := database.Database.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+
+	err := services.DeleteRole(role, uint(c.MustGet("user_id").(float64)), c.GetString("role"))
+	if errors.Is(err, services.ErrPermissionNotHeld) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrSystemRole) || errors.Is(err, services.ErrRoleInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// SetUserRole godoc
+// @Summary      Change a user's role
+// @Description  Sets the global role of a user. The new role can only hold permissions the caller holds, and users holding permissions the caller lacks cannot be changed. The last user with every permission cannot be demoted.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                 true  "User ID"
+// @Param        payload  body      SetUserRoleRequest  true  "Role name"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Role updated successfully"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Role not found"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot grant permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "cannot remove the last user with every permission"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update role"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/role [put]
+func SetUserRole(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var payload SetUserRoleRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.SetUserRole(user, payload.Role, uint(c.MustGet("user_id").(float64)), c.GetString("role"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found"})
+		return
+	} else if errors.Is(err, services.ErrPermissionNotHeld) || errors.Is(err, services.ErrTargetMorePrivileged) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrLastAdmin) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionChangeRole, user.ID, map[string]interface{}{
+		"from": user.Role,
+		"to":   payload.Role,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+// ListRoleAssignments godoc
+// @Summary      List category role assignments
+// @Description  Returns the roles users hold for single categories, optionally for one user
+// @Tags         admin
+// @Produce      json
+// @Param        user_id  query     int  false  "User ID"
+// @Success      200  {array}   model.RoleAssignment
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve role assignments"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/role-assignments [get]
+func ListRoleAssignments(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Query("user_id"))
+
+	assignments, err := services.ListRoleAssignments(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve role assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// AssignRole godoc
+// @Summary      Assign a role for a category
+// @Description  Grants a user a role within a single category, e.g. to make them moderator of one board. Only the role's category permissions apply.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      AssignRoleRequest  true  "User, role and category"
+// @Success      201  {object}  model.RoleAssignment
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot grant permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "Role already assigned"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to assign role"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/role-assignments [post]
+func AssignRole(c *gin.Context) {
+	var payload AssignRoleRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.Database.First(&model.User{}, payload.UserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := database.Database.First(&model.Role{}, payload.RoleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	}
+	if err := database.Database.First(&model.Category{}, payload.CategoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	assignedByID := uint(c.MustGet("user_id").(float64))
+	assignment, err := services.AssignRole(payload.UserID, payload.RoleID, payload.CategoryID, assignedByID, c.GetString("role"))
+	if errors.Is(err, services.ErrPermissionNotHeld) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Role already assigned"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign role"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// UnassignRole godoc
+// @Summary      Remove a category role assignment
+// @Description  Removes a category role assignment. The caller has to hold the role's permissions in that category and may not remove assignments from users who hold permissions the caller lacks.
+// @Tags         admin
+// @Produce      json
+// @Param        id  path      int  true  "Assignment ID"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Role assignment removed successfully"}"
+// @Failure      403  {object}  map[string]interface{}  "{"error": "cannot act on a user with permissions you do not hold"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "Role assignment not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to remove role assignment"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/role-assignments/{id} [delete]
+func UnassignRole(c *gin.Context) {
+	assignmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role assignment not found"})
+		return
+	}
+
+	err = services.UnassignRole(uint(assignmentID), uint(c.MustGet("user_id").(float64)), c.GetString("role"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role assignment not found"})
+		return
+	} else if errors.Is(err, services.ErrPermissionNotHeld) || errors.Is(err, services.ErrTargetMorePrivileged) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove role assignment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role assignment removed successfully"})
+}
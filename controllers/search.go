@@ -4,14 +4,16 @@ import (
 	"net/http"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // SearchPostTitle godoc
 // @Summary      Search posts by title
-// @Description  Searches for master posts by title within a specified category. Must include `category` and `title` query parameters.
+// @Description  Searches for master posts by title within a specified category. Must include `category` and `title` query parameters. For signed-in users, their page size applies and posts in muted categories, and NSFW categories if they hide NSFW content, are left out.
 // @Tags         search
 // @Accept       json
 // @Produce      json
@@ -45,11 +47,12 @@ func SearchPostTitle(c *gin.Context) {
 	}
 
 	var posts []model.Post
+	pageSize := viewerPageSize(c)
 	offset := (page - 1) * pageSize
+	query := services.ExcludeHiddenCategories(database.Database.Model(&model.Post{}).Where("title LIKE ?", "%"+title+"%"), viewerPreferences(c))
 
-	if err := database.Database.
+	if err := query.Session(&gorm.Session{}).
 		Preload("User").
-		Where("title LIKE ?", "%"+title+"%").
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -59,10 +62,7 @@ func SearchPostTitle(c *gin.Context) {
 	}
 
 	var count int64
-	if err := database.Database.
-		Model(&model.Post{}).
-		Where("title LIKE ?", "%"+title+"%").
-		Count(&count).Error; err != nil {
+	if err := query.Count(&count).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post count"})
 		return
 	}
@@ -75,7 +75,7 @@ func SearchPostTitle(c *gin.Context) {
 
 // SearchPostReplies godoc
 // @Summary      Search replies to a specific post
-// @Description  Searches for replies (posts) by content for a given parent post ID. Must provide the parent post's ID via query parameter `id`.
+// @Description  Searches for replies (posts) by content for a given parent post ID. Must provide the parent post's ID via query parameter `id`. For signed-in users, their page size applies.
 // @Tags         search
 // @Accept       json
 // @Produce      json
@@ -98,12 +98,17 @@ func SearchPostReplies(c *gin.Context) {
 	}
 
 	var parentPost model.Post
-	if err := database.Database.First(&parentPost, parentPostID).Error; err != nil {
+	if err := database.Database.Preload("Category").First(&parentPost, parentPostID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Parent post not found"})
 		return
 	}
 
+	if nsfwHidden(c, parentPost.Category) {
+		return
+	}
+
 	var posts []model.Post
+	pageSize := viewerPageSize(c)
 	offset := (page - 1) * pageSize
 
 	if err := database.Database.
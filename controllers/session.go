@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/services"
+	"onichan/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// cookieMode reports whether the client asked for its session in cookies
+// with the X-Auth-Mode: cookie header.
+func cookieMode(c *gin.Context) bool {
+	return c.GetHeader("X-Auth-Mode") == "cookie"
+}
+
+// respondWithSession sends a new token pair either in the response body or,
+// in cookie mode, as HttpOnly cookies together with a fresh CSRF token.
+func respondWithSession(c *gin.Context, tokens services.TokenPair, useCookies bool) {
+	if !useCookies {
+		c.JSON(http.StatusOK, tokens)
+		return
+	}
+
+	refreshMaxAge := services.REFRESH_TOKEN_TTL * 3600
+	csrfToken := utils.GenerateSecureToken(32)
+
+	http.SetCookie(c.Writer, utils.NewCookie(utils.AccessTokenCookie, tokens.Token, "/", tokens.ExpiresIn, true))
+	http.SetCookie(c.Writer, utils.NewCookie(utils.RefreshTokenCookie, tokens.RefreshToken, utils.RefreshCookiePath, refreshMaxAge, true))
+	http.SetCookie(c.Writer, utils.NewCookie(utils.CSRFCookie, csrfToken, "/", refreshMaxAge, false))
+
+	c.JSON(http.StatusOK, gin.H{
+		"expires_in": tokens.ExpiresIn,
+		"csrf_token": csrfToken,
+	})
+}
+
+func clearSessionCookies(c *gin.Context) {
+	http.SetCookie(c.Writer, utils.NewCookie(utils.AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(c.Writer, utils.NewCookie(utils.RefreshTokenCookie, "", utils.RefreshCookiePath, -1, true))
+	http.SetCookie(c.Writer, utils.NewCookie(utils.CSRFCookie, "", "/", -1, false))
+}
+
+// RefreshToken godoc
+// @Summary      Refresh an access token
+// @Description  Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can only be used once; reusing one revokes the whole session. In cookie mode the body can be left out; the refresh_token cookie is used and the new tokens are set as cookies.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        refreshTokenRequest  body      RefreshTokenRequest  true  "Refresh token"
+// @Success      200  {object}  services.TokenPair
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Bad request"}"
+// @Failure      401  {object}  map[string]interface{}  "{"error": "Invalid or expired refresh token"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to refresh session"}"
+// @Router       /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var payload RefreshTokenRequest
+	// The body is optional in cookie mode.
+	c.ShouldBindJSON(&payload)
+
+	useCookies := cookieMode(c)
+	if payload.RefreshToken == "" {
+		cookie, err := c.Cookie(utils.RefreshTokenCookie)
+		if err != nil || cookie == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+			return
+		}
+		payload.RefreshToken = cookie
+		useCookies = true
+	}
+
+	tokens, err := services.RefreshSession(payload.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	respondWithSession(c, tokens, useCookies)
+}
+
+// Logout godoc
+// @Summary      Log out of the current session
+// @Description  Revokes the session the access token belongs to. Its access and refresh tokens stop working immediately, and session cookies are cleared.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"message": "Logged out successfully"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to revoke session"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/logout [post]
+func Logout(c *gin.Context) {
+	if err := services.RevokeSession(c.GetUint("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll godoc
+// @Summary      Log out of every session
+// @Description  Revokes all sessions of the current user, including the current one. API tokens stay valid unless api_tokens is true.
+// @Tags         auth
+// @Produce      json
+// @Param        api_tokens  query     bool  false  "Also revoke every API token"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Logged out of all sessions"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to revoke sessions"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/logout-all [post]
+func LogoutAll(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	if err := services.RevokeUserSessions(userID, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	if c.Query("api_tokens") == "true" {
+		if err := services.RevokeUserAPITokens(userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API tokens"})
+			return
+		}
+	}
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+// ListSessions godoc
+// @Summary      List active sessions
+// @Description  Lists the active sessions of the current user with their device, IP address and last activity. The session making the request is flagged as current.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   SessionResponse
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve sessions"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+	currentSessionID := c.GetUint("session_id")
+
+	sessions, err := services.ListActiveSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WebSocketToken godoc
+// @Summary      Get a websocket token
+// @Description  Returns a token that is valid for one minute and only for opening the websocket at `/ws?token=...`. Access tokens are not accepted there, so they never end up in URLs.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"token": "..."}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to generate token"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/ws-token [post]
+func WebSocketToken(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	token, err := utils.GenerateWebSocketToken(userID, c.GetUint("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"onichan/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSettings godoc
+// @Summary      List runtime settings
+// @Description  Returns every admin-configurable setting with its current value.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve settings"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/settings [get]
+func ListSettings(c *gin.Context) {
+	settings, err := services.ListSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings godoc
+// @Summary      Update runtime settings
+// @Description  Updates the given settings, e.g. {"require_admin_2fa": true} to enforce two-factor authentication for staff, i.e. users holding any permission.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        data  body      map[string]interface{}  true  "Settings to update"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]interface{}  "{"error": "unknown setting: foo"}"
+// @Failure      500   {object}  map[string]interface{}  "{"error": "Failed to update settings"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/settings [patch]
+func UpdateSettings(c *gin.Context) {
+	var payload map[string]interface{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for key, value := range payload {
+		if err := services.ValidateSetting(key, fmt.Sprint(value)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error() + ": " + key})
+			return
+		}
+	}
+
+	for key, value := range payload {
+		if err := services.SetSetting(key, fmt.Sprint(value)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+			return
+		}
+	}
+
+	ListSettings(c)
+}
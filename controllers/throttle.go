@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"onichan/model"
+	"onichan/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// throttled responds with 429 and returns true while any of the keys is
+// locked out.
+func throttled(c *gin.Context, keys ...string) bool {
+	wait := services.ThrottleWait(keys...)
+	if wait <= 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many attempts, please try again later",
+		"retry_after": seconds,
+	})
+	return true
+}
+
+// recordFailure counts a failed attempt against every key. If the attempt
+// locks out the account key and the account exists, its owner is told by
+// email.
+func recordFailure(user *model.User, accountKey string, keys ...string) {
+	if _, locked, err := services.RecordThrottleFailure(accountKey); err == nil && locked && user != nil {
+		go services.SendLockoutEmail(*user)
+	}
+
+	for _, key := range keys {
+		services.RecordThrottleFailure(key)
+	}
+}
+
+// ListLockouts godoc
+// @Summary      List throttled keys
+// @Description  Returns every account, IP or email key with recent failed attempts and, if locked out, until when.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   model.LoginThrottle
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to retrieve lockouts"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/lockouts [get]
+func ListLockouts(c *gin.Context) {
+	entries, err := services.ListThrottles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lockouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ClearLockout godoc
+// @Summary      Clear a lockout
+// @Description  Resets the failure counter of a key, e.g. `login:account:alice`, lifting any lockout.
+// @Tags         admin
+// @Produce      json
+// @Param        key  path      string  true  "Throttle key"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Lockout cleared"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to clear lockout"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/lockouts/{key} [delete]
+func ClearLockout(c *gin.Context) {
+	if err := services.ClearThrottle(c.Param("key")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear lockout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
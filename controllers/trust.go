@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetTrustLevelRequest struct {
+	Level *int `json:"level"`
+}
+
+// trustLevelTooLow responds with an error when the user's trust level is
+// below the one required by the setting.
+func trustLevelTooLow(c *gin.Context, setting, message string) bool {
+	userID := uint(c.MustGet("user_id").(float64))
+	if services.MeetsTrustLevel(userID, c.GetString("role"), c.GetInt("trust_level"), setting) {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": message, "required_trust_level": services.GetIntSetting(setting)})
+	return true
+}
+
+// linksNotAllowed responds with an error when the text contains links the
+// user is not trusted to post yet.
+func linksNotAllowed(c *gin.Context, title *string, content string) bool {
+	texts := []string{content}
+	if title != nil {
+		texts = append(texts, *title)
+	}
+	if !services.ContainsLink(texts...) {
+		return false
+	}
+
+	return trustLevelTooLow(c, services.SettingTrustLevelPostLinks, "Your trust level is too low to post links")
+}
+
+// SetTrustLevel godoc
+// @Summary      Pin a user's trust level
+// @Description  Pins the trust level of a user to a level from 0 to 4 so it is no longer computed automatically. Send a null level to unpin it and recompute it from the user's activity.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                   true  "User ID"
+// @Param        payload  body      SetTrustLevelRequest  true  "Trust level"
+// @Success      200  {object}  model.Account
+// @Failure      400  {object}  map[string]interface{}  "{"error": "trust level must be between 0 and 4"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to update trust level"}"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/trust-level [put]
+func SetTrustLevel(c *gin.Context) {
+	var user model.User
+	if err := database.Database.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var payload SetTrustLevelRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := user.TrustLevel
+	user, err := services.SetTrustLevel(user, payload.Level)
+	if errors.Is(err, services.ErrInvalidTrustLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update trust level"})
+		return
+	}
+
+	services.RecordAudit(uint(c.MustGet("user_id").(float64)), model.AuditActionSetTrustLevel, user.ID, map[string]interface{}{
+		"from":   from,
+		"to":     user.TrustLevel,
+		"locked": user.TrustLevelLocked,
+	})
+
+	c.JSON(http.StatusOK, user.Account())
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"onichan/database"
+	"onichan/model"
+	"onichan/services"
+	"onichan/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyTwoFactorRequest struct {
+	Challenge    string `json:"challenge" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// EnrollTwoFactor godoc
+// @Summary      Start two-factor enrollment
+// @Description  Generates a new TOTP secret for the current user. The secret and otpauth:// URI are meant to be shown as text or a QR code; two-factor authentication is only enabled after confirmation.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "{"secret": "BASE32SECRET", "otpauth_url": "otpauth://totp/..."}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "two-factor authentication is already enabled"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to start enrollment"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/2fa/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	secret, uri, err := services.StartTwoFactorEnrollment(user)
+	if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_url": uri,
+	})
+}
+
+// ConfirmTwoFactor godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Enables two-factor authentication after checking a code from the authenticator app, and returns one-time recovery codes. The recovery codes are shown only once.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        confirmTwoFactorRequest  body      ConfirmTwoFactorRequest  true  "TOTP code"
+// @Success      200  {object}  map[string]interface{}  "{"recovery_codes": ["abcde-fghij", ...]}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid two-factor code"}"
+// @Failure      409  {object}  map[string]interface{}  "{"error": "two-factor authentication is already enabled"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to enable two-factor authentication"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	var payload ConfirmTwoFactorRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := uint(c.MustGet("user_id").(float64))
+
+	codes, err := services.ConfirmTwoFactor(userID, payload.Code)
+	if errors.Is(err, services.ErrInvalidTwoFactorCode) || errors.Is(err, services.ErrTwoFactorNotEnrolled) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turns off two-factor authentication for the current user. Requires the password and either a TOTP code or a recovery code.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        disableTwoFactorRequest  body      DisableTwoFactorRequest  true  "Credentials"
+// @Success      200  {object}  map[string]interface{}  "{"message": "Two-factor authentication disabled"}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "Invalid password"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error": "User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to disable two-factor authentication"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var payload DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !services.CheckPassword(user, payload.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	if err := services.VerifySecondFactor(user.ID, payload.Code, payload.RecoveryCode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.DisableTwoFactor(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replaces all recovery codes of the current user after checking a TOTP code. The old codes stop working.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        regenerateRecoveryCodesRequest  body      RegenerateRecoveryCodesRequest  true  "TOTP code"
+// @Success      200  {object}  map[string]interface{}  "{"recovery_codes": ["abcde-fghij", ...]}"
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid two-factor code"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to generate recovery codes"}"
+// @Security     ApiKeyAuth
+// @Router       /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var payload RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := uint(c.MustGet("user_id").(float64))
+
+	if err := services.VerifySecondFactor(userID, payload.Code, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := services.GenerateRecoveryCodes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// VerifyTwoFactor godoc
+// @Summary      Complete a two-factor login
+// @Description  Exchanges the challenge returned by login, together with a TOTP code or a recovery code, for a session.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        verifyTwoFactorRequest  body      VerifyTwoFactorRequest  true  "Challenge and code"
+// @Success      200  {object}  services.TokenPair
+// @Failure      400  {object}  map[string]interface{}  "{"error": "invalid two-factor code"}"
+// @Failure      401  {object}  map[string]interface{}  "{"error": "Invalid or expired challenge"}"
+// @Failure      429  {object}  map[string]interface{}  "{"error": "Too many attempts, please try again later"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error": "Failed to generate token"}"
+// @Router       /auth/2fa/verify [post]
+func VerifyTwoFactor(c *gin.Context) {
+	var payload VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := utils.ValidateTwoFactorChallenge(payload.Challenge)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	accountKey := "2fa:account:" + strconv.Itoa(int(user.ID))
+	ipKey := "2fa:ip:" + c.ClientIP()
+	if throttled(c, accountKey, ipKey) {
+		return
+	}
+
+	if err := services.VerifySecondFactor(user.ID, payload.Code, payload.RecoveryCode); err != nil {
+		recordFailure(&user, accountKey, ipKey)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	services.ClearThrottle(accountKey)
+	startSession(c, user)
+}
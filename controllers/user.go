@@ -2,30 +2,284 @@ package controllers
 
 import (
 	"net/http"
+	"net/url"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const maxProfileLinks = 5
+
+// UpdateProfileRequest holds the profile fields to change. Omitted fields are
+// left alone and empty strings clear a field.
+type UpdateProfileRequest struct {
+	DisplayName *string   `json:"display_name"`
+	Bio         *string   `json:"bio"`
+	Signature   *string   `json:"signature"`
+	Location    *string   `json:"location"`
+	Links       *[]string `json:"links"`
+}
+
 // GetUser godoc
 // @Summary      Get user by ID
-// @Description  Retrieves a single user by their ID
+// @Description  Retrieves a user's public profile with their post statistics
 // @Tags         users
 // @Produce      json
 // @Param        id   path      int   true  "User ID"
-// @Success      200  {object}  model.User
+// @Success      200  {object}  model.PublicUser
 // @Failure      404  {object}  map[string]interface{}  "{"error":"User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error":"Failed to retrieve user statistics"}"
 // @Router       /users/{id} [get]
 func GetUser(c *gin.Context) {
+	user, ok := findPublicUser(c)
+	if !ok {
+		return
+	}
+
+	stats, err := services.GetUserStats(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user statistics"})
+		return
+	}
+
+	profile := user.Public()
+	profile.Stats = &stats
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// findPublicUser loads the user from the id path parameter, hiding accounts
+// that are still awaiting approval.
+func findPublicUser(c *gin.Context) (model.User, bool) {
 	var user model.User
+	if err := database.Database.First(&user, "id = ? AND status = ?", c.Param("id"), model.UserStatusActive).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Retrieves the current user's account, including their email
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  model.Account
+// @Failure      404  {object}  map[string]interface{}  "{"error":"User not found"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me [get]
+func GetMe(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
 
-	if err := database.Database.Find(&user, c.Param("id")).Error; err != nil {
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, user.Account())
+}
+
+// UpdateProfile godoc
+// @Summary      Update the current user's profile
+// @Description  Updates the given profile fields. Display name and location take up to 63 characters, the signature up to 255 and the bio up to 2000. Up to 5 http(s) links are allowed. Send an empty string to clear a field.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      UpdateProfileRequest  true  "Profile fields"
+// @Success      200  {object}  model.Account
+// @Failure      400  {object}  map[string]interface{}  "{"error":"Display name must contain at most 63 characters"}"
+// @Failure      404  {object}  map[string]interface{}  "{"error":"User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error":"Failed to update profile"}"
+// @Security     ApiKeyAuth
+// @Router       /users/me [patch]
+func UpdateProfile(c *gin.Context) {
+	userID := uint(c.MustGet("user_id").(float64))
+
+	var payload UpdateProfileRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if ok, message := validateProfile(payload); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var columns []string
+	if payload.DisplayName != nil {
+		user.DisplayName = trimmedOrNil(*payload.DisplayName)
+		columns = append(columns, "display_name")
+	}
+	if payload.Bio != nil {
+		user.Bio = trimmedOrNil(*payload.Bio)
+		columns = append(columns, "bio")
+	}
+	if payload.Signature != nil {
+		user.Signature = trimmedOrNil(*payload.Signature)
+		columns = append(columns, "signature")
+	}
+	if payload.Location != nil {
+		user.Location = trimmedOrNil(*payload.Location)
+		columns = append(columns, "location")
+	}
+	if payload.Links != nil {
+		user.Links = *payload.Links
+		columns = append(columns, "links")
+	}
+
+	if len(columns) > 0 {
+		if err := database.Database.Model(&user).Select(columns).Updates(&user).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, user.Account())
+}
+
+func trimmedOrNil(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func validateProfile(payload UpdateProfileRequest) (bool, string) {
+	limits := []struct {
+		value *string
+		name  string
+		max   int
+	}{
+		{payload.DisplayName, "Display name", 63},
+		{payload.Bio, "Bio", 2000},
+		{payload.Signature, "Signature", 255},
+		{payload.Location, "Location", 63},
+	}
+	for _, limit := range limits {
+		if limit.value != nil && utf8.RuneCountInString(strings.TrimSpace(*limit.value)) > limit.max {
+			return false, limit.name + " must contain at most " + strconv.Itoa(limit.max) + " characters"
+		}
+	}
+
+	if payload.Links != nil {
+		if len(*payload.Links) > maxProfileLinks {
+			return false, "A profile can have at most " + strconv.Itoa(maxProfileLinks) + " links"
+		}
+		for _, link := range *payload.Links {
+			parsed, err := url.Parse(link)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(link) > 255 {
+				return false, "Links must be http or https URLs of at most 255 characters"
+			}
+		}
+	}
+
+	return true, ""
+}
+
+// GetUserPosts godoc
+// @Summary      List a user's posts
+// @Description  Retrieves a paginated list of the user's posts and replies, newest first. Replies include the page of their thread they appear on. For signed-in users, their page size applies and posts in categories they hide are left out.
+// @Tags         users
+// @Produce      json
+// @Param        id    path      int  true   "User ID"
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"posts": [...], "total_pages": X}"
+// @Failure      404  {object}  map[string]interface{}  "{"error":"User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error":"Failed to retrieve posts"}"
+// @Router       /users/{id}/posts [get]
+func GetUserPosts(c *gin.Context) {
+	listUserPosts(c, false)
+}
+
+// GetUserThreads godoc
+// @Summary      List the threads a user started
+// @Description  Retrieves a paginated list of the master posts the user created, newest first. For signed-in users, their page size applies and threads in categories they hide are left out.
+// @Tags         users
+// @Produce      json
+// @Param        id    path      int  true   "User ID"
+// @Param        page  query     int  false  "Page number" default(1)
+// @Success      200  {object}  map[string]interface{}  "{"posts": [...], "total_pages": X}"
+// @Failure      404  {object}  map[string]interface{}  "{"error":"User not found"}"
+// @Failure      500  {object}  map[string]interface{}  "{"error":"Failed to retrieve posts"}"
+// @Router       /users/{id}/threads [get]
+func GetUserThreads(c *gin.Context) {
+	listUserPosts(c, true)
+}
+
+func listUserPosts(c *gin.Context, threadsOnly bool) {
+	user, ok := findPublicUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize := viewerPageSize(c)
+	offset := (page - 1) * pageSize
+
+	query := database.Database.Model(&model.Post{}).Where("user_id = ? AND is_deleted = ?", user.ID, false)
+	if threadsOnly {
+		query = query.Where("is_master_post = ?", true)
+	}
+	query = services.ExcludeHiddenCategories(query, viewerPreferences(c))
+
+	var posts []model.Post
+	if err := query.Session(&gorm.Session{}).
+		Preload("User").
+		Preload("Category").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		return
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		return
+	}
+
+	for index := range posts {
+		if posts[index].IsMasterPost {
+			var replyCount int64
+			database.Database.Model(&model.Post{}).
+				Where("parent_post_id = ?", posts[index].ID).
+				Count(&replyCount)
+			posts[index].RepliesCount = int(replyCount)
+			continue
+		}
+
+		var before int64
+		if err := database.Database.
+			Model(&model.Post{}).
+			Where("parent_post_id = ? AND created_at < ?", posts[index].ParentPostID, posts[index].CreatedAt).
+			Count(&before).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post order"})
+			return
+		}
+		posts[index].Page = (int(before) + pageSize + 1) / pageSize
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":       posts,
+		"total_pages": (int(count) + pageSize - 1) / pageSize,
+	})
 }
 
 // GetAllAvatars godoc
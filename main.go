@@ -5,11 +5,13 @@ import (
 	"onichan/database"
 	_ "onichan/docs"
 	"onichan/middleware"
+	"onichan/model"
 	"onichan/services"
 	"onichan/utils"
 	"onichan/websocket"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -19,12 +21,30 @@ import (
 func main() {
 	utils.LoadEnv()
 	utils.LoadJWT()
+	utils.LoadPasswordHashing()
+	utils.LoadCookieEnv()
 	services.LoadEnv()
+	services.LoadSessionEnv()
+	services.LoadPasswordResetEnv()
+	services.LoadVerificationEnv()
+	services.LoadOIDCEnv()
+	services.LoadThrottleEnv()
+	services.LoadMagicLinkEnv()
+	services.LoadChallengeEnv()
+	services.LoadAccountDeletionEnv()
+	services.LoadNotificationEnv()
 	database.Connect()
 	controllers.LoadPageSize()
 
+	go services.StartUnverifiedUserCleanup(time.Hour)
+	go utils.StartKeyReload(time.Minute)
+	go services.StartTrustLevelUpdates(time.Hour)
+	go services.StartAccountDeletions(time.Hour)
+	go services.StartThrottleSweep(10 * time.Minute)
+
 	r := gin.Default()
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	r.GET("/.well-known/jwks.json", controllers.GetJWKS)
 
 	maxFileSize, _ := strconv.Atoi(os.Getenv("MAX_FILE_SIZE"))
 	r.MaxMultipartMemory = int64(maxFileSize)
@@ -32,77 +52,178 @@ func main() {
 	r.Use(middleware.CORSMiddleware())
 
 	api := r.Group("api")
+	api.Use(middleware.CSRFMiddleware())
 
-	api.POST("/upload", middleware.JWTMiddleware(database.Database), controllers.UploadImage)
-	api.Static("/uploads", os.Getenv("UPLOAD_PATH"))
+	api.POST("/upload", middleware.JWTMiddleware(database.Database, model.ScopeUploadsWrite), middleware.RequireVerifiedEmail("upload"), middleware.RequireTrustLevel(services.SettingTrustLevelUpload), controllers.UploadImage)
+	api.Static("/uploads", utils.UploadPath())
+	api.GET("/challenge", controllers.GetChallenge)
 
 	authRoute := api.Group("/auth")
 	{
-		authRoute.POST("/register", middleware.CORSMiddleware(), controllers.Register)
+		authRoute.POST("/register", middleware.CORSMiddleware(), middleware.RequireChallenge(services.ChallengeActionRegister), controllers.Register)
 		authRoute.POST("/login", middleware.CORSMiddleware(), controllers.Login)
 		authRoute.PATCH("/change-password", middleware.JWTMiddleware(database.Database), controllers.ChangePassword)
 		authRoute.PATCH("/change-email", middleware.JWTMiddleware(database.Database), controllers.ChangeEmail)
 		authRoute.PATCH("/change-avatar", middleware.JWTMiddleware(database.Database), controllers.ChangeAvatar)
 		authRoute.POST("/forgot-password", controllers.ForgotPassword)
 		authRoute.POST("/reset-password", controllers.ResetForgottenPassword)
+		authRoute.POST("/magic-link", controllers.RequestMagicLink)
+		authRoute.POST("/magic-link/consume", controllers.ConsumeMagicLink)
+		authRoute.POST("/verify-email", controllers.VerifyEmail)
+		authRoute.POST("/resend-verification", middleware.JWTMiddleware(database.Database), controllers.ResendVerification)
+		authRoute.POST("/refresh", controllers.RefreshToken)
+		authRoute.POST("/logout", middleware.JWTMiddleware(database.Database), controllers.Logout)
+		authRoute.POST("/logout-all", middleware.JWTMiddleware(database.Database), controllers.LogoutAll)
+		authRoute.GET("/sessions", middleware.JWTMiddleware(database.Database), controllers.ListSessions)
+		authRoute.POST("/ws-token", middleware.JWTMiddleware(database.Database), controllers.WebSocketToken)
+		authRoute.POST("/2fa/enroll", middleware.JWTMiddleware(database.Database), controllers.EnrollTwoFactor)
+		authRoute.POST("/2fa/confirm", middleware.JWTMiddleware(database.Database), controllers.ConfirmTwoFactor)
+		authRoute.POST("/2fa/disable", middleware.JWTMiddleware(database.Database), controllers.DisableTwoFactor)
+		authRoute.POST("/2fa/recovery-codes", middleware.JWTMiddleware(database.Database), controllers.RegenerateRecoveryCodes)
+		authRoute.POST("/2fa/verify", controllers.VerifyTwoFactor)
+		authRoute.GET("/oauth/providers", controllers.ListIdentityProviders)
+		authRoute.GET("/oauth/:provider/authorize", controllers.OAuthAuthorize)
+		authRoute.POST("/oauth/:provider/callback", middleware.OptionalAuth(database.Database), controllers.OAuthCallback)
+		authRoute.POST("/oauth/:provider/link", middleware.JWTMiddleware(database.Database), controllers.LinkIdentity)
+		authRoute.POST("/oauth/register", controllers.OAuthRegister)
+		authRoute.GET("/identities", middleware.JWTMiddleware(database.Database), controllers.ListIdentities)
+		authRoute.DELETE("/identities/:id", middleware.JWTMiddleware(database.Database), controllers.UnlinkIdentity)
+		authRoute.POST("/tokens", middleware.JWTMiddleware(database.Database), controllers.CreateAPIToken)
+		authRoute.GET("/tokens", middleware.JWTMiddleware(database.Database), controllers.ListAPITokens)
+		authRoute.DELETE("/tokens/:id", middleware.JWTMiddleware(database.Database), controllers.RevokeAPIToken)
 	}
 
 	userRoute := api.Group("/users")
 	{
+		userRoute.GET("/me", middleware.JWTMiddleware(database.Database), controllers.GetMe)
+		userRoute.PATCH("/me", middleware.JWTMiddleware(database.Database), controllers.UpdateProfile)
+		userRoute.DELETE("/me", middleware.JWTMiddleware(database.Database), controllers.DeleteMe)
+		userRoute.POST("/me/restore", middleware.JWTMiddleware(database.Database), controllers.RestoreMe)
+		userRoute.GET("/me/export", middleware.JWTMiddleware(database.Database), controllers.ExportMe)
+		userRoute.POST("/me/avatar", middleware.JWTMiddleware(database.Database), middleware.RequireVerifiedEmail("upload"), controllers.UploadAvatar)
+		userRoute.GET("/me/preferences", middleware.JWTMiddleware(database.Database), controllers.GetPreferences)
+		userRoute.PATCH("/me/preferences", middleware.JWTMiddleware(database.Database), controllers.UpdatePreferences)
 		userRoute.GET("/:id", controllers.GetUser)
+		userRoute.GET("/:id/posts", middleware.OptionalAuth(database.Database), controllers.GetUserPosts)
+		userRoute.GET("/:id/threads", middleware.OptionalAuth(database.Database), controllers.GetUserThreads)
+		userRoute.GET("/:id/followers", controllers.ListFollowers)
+		userRoute.GET("/:id/following", controllers.ListFollowing)
+		userRoute.POST("/:id/follow", middleware.JWTMiddleware(database.Database), controllers.FollowUser)
+		userRoute.DELETE("/:id/follow", middleware.JWTMiddleware(database.Database), controllers.UnfollowUser)
+		userRoute.GET("/me/blocks", middleware.JWTMiddleware(database.Database), controllers.ListBlocks)
+		userRoute.POST("/:id/block", middleware.JWTMiddleware(database.Database), controllers.BlockUser)
+		userRoute.DELETE("/:id/block", middleware.JWTMiddleware(database.Database), controllers.UnblockUser)
+		userRoute.POST("/:id/mute", middleware.JWTMiddleware(database.Database), controllers.MuteUser)
+		userRoute.DELETE("/:id/mute", middleware.JWTMiddleware(database.Database), controllers.UnmuteUser)
 		userRoute.GET("/avatars", controllers.GetAllAvatars)
 	}
 
 	categoryRoute := api.Group("/categories")
 	{
-		categoryRoute.POST("", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.CreateCategory)
+		categoryRoute.POST("", middleware.JWTMiddleware(database.Database, model.ScopeAdminCategories), middleware.RequirePermission(model.PermissionManageCategories), controllers.CreateCategory)
 		categoryRoute.GET("", controllers.ListCategories)
 		categoryRoute.GET("/:id", controllers.GetCategory)
-		categoryRoute.PUT("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.UpdateCategory)
-		categoryRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.PatchCategory)
-		categoryRoute.DELETE("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.DeleteCategory)
+		categoryRoute.PUT("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminCategories), middleware.RequirePermission(model.PermissionManageCategories), controllers.UpdateCategory)
+		categoryRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminCategories), middleware.RequirePermission(model.PermissionManageCategories), controllers.PatchCategory)
+		categoryRoute.DELETE("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminCategories), middleware.RequirePermission(model.PermissionManageCategories), controllers.DeleteCategory)
+		categoryRoute.POST("/:id/follow", middleware.JWTMiddleware(database.Database), controllers.FollowCategory)
+		categoryRoute.DELETE("/:id/follow", middleware.JWTMiddleware(database.Database), controllers.UnfollowCategory)
 	}
 
 	reactionRoute := api.Group("/reactions")
 	{
-		reactionRoute.POST("", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.CreateReaction)
+		reactionRoute.POST("", middleware.JWTMiddleware(database.Database, model.ScopeAdminReactions), middleware.RequirePermission(model.PermissionManageReactions), controllers.CreateReaction)
 		reactionRoute.GET("", controllers.ListReactions)
 		reactionRoute.GET("/:id", controllers.GetReaction)
-		reactionRoute.PUT("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.UpdateReaction)
-		reactionRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.PatchReaction)
-		reactionRoute.DELETE("/:id", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.DeleteReaction)
+		reactionRoute.PUT("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminReactions), middleware.RequirePermission(model.PermissionManageReactions), controllers.UpdateReaction)
+		reactionRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminReactions), middleware.RequirePermission(model.PermissionManageReactions), controllers.PatchReaction)
+		reactionRoute.DELETE("/:id", middleware.JWTMiddleware(database.Database, model.ScopeAdminReactions), middleware.RequirePermission(model.PermissionManageReactions), controllers.DeleteReaction)
 	}
 
 	postRoute := api.Group("/posts")
 	{
-		postRoute.POST("", middleware.JWTMiddleware(database.Database), controllers.CreatePost)
-		postRoute.GET("", controllers.ListPosts)
-		postRoute.GET("/:id", controllers.GetPost)
-		postRoute.PUT("/:id", middleware.JWTMiddleware(database.Database), controllers.UpdatePost)
-		postRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database), controllers.PatchPost)
-		postRoute.PUT("/reactions", middleware.JWTMiddleware(database.Database), controllers.ToggleReaction)
+		postRoute.POST("", middleware.JWTMiddleware(database.Database, model.ScopePostsWrite), middleware.RequireVerifiedEmail("create_post"), middleware.RequireChallenge(services.ChallengeActionPost), controllers.CreatePost)
+		postRoute.GET("", middleware.OptionalAuth(database.Database), controllers.ListPosts)
+		postRoute.GET("/:id", middleware.OptionalAuth(database.Database), controllers.GetPost)
+		postRoute.PUT("/:id", middleware.JWTMiddleware(database.Database, model.ScopePostsWrite), controllers.UpdatePost)
+		postRoute.PATCH("/:id", middleware.JWTMiddleware(database.Database, model.ScopePostsWrite), controllers.PatchPost)
+		postRoute.PUT("/reactions", middleware.JWTMiddleware(database.Database, model.ScopeReactionsWrite), middleware.RequireVerifiedEmail("react"), controllers.ToggleReaction)
 	}
 
+	api.GET("/feed", middleware.JWTMiddleware(database.Database), controllers.GetFeed)
+
 	notificationRoute := api.Group("notifications")
 	{
-		notificationRoute.GET("", middleware.JWTMiddleware(database.Database), controllers.GetUnreadNotifications)
-		notificationRoute.PATCH("", middleware.JWTMiddleware(database.Database), controllers.ReadNotifications)
+		notificationRoute.GET("", middleware.JWTMiddleware(database.Database, model.ScopeNotificationsRead), controllers.GetUnreadNotifications)
+		notificationRoute.PATCH("", middleware.JWTMiddleware(database.Database, model.ScopeNotificationsWrite), controllers.ReadNotifications)
 	}
 
 	searchRoute := api.Group("/search")
 	{
-		searchRoute.GET("/title", controllers.SearchPostTitle)
-		searchRoute.GET("/posts", controllers.SearchPostReplies)
+		searchRoute.GET("/title", middleware.OptionalAuth(database.Database), controllers.SearchPostTitle)
+		searchRoute.GET("/posts", middleware.OptionalAuth(database.Database), controllers.SearchPostReplies)
 	}
 
 	reportRoute := api.Group("/reports")
 	{
-		reportRoute.POST("", middleware.JWTMiddleware(database.Database), controllers.CreateReport)
-		reportRoute.GET("", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.ListReports)
-		reportRoute.PATCH("", middleware.JWTMiddleware(database.Database), middleware.AdminOnly(), controllers.ResolveReport)
+		reportRoute.POST("", middleware.JWTMiddleware(database.Database, model.ScopeReportsWrite), middleware.RequireVerifiedEmail("report"), controllers.CreateReport)
+		reportRoute.GET("", middleware.JWTMiddleware(database.Database, model.ScopeAdminReports), middleware.RequirePermission(model.PermissionResolveReports), controllers.ListReports)
+		reportRoute.PATCH("", middleware.JWTMiddleware(database.Database, model.ScopeAdminReports), middleware.RequirePermission(model.PermissionResolveReports), controllers.ResolveReport)
+	}
+
+	conversationRoute := api.Group("/conversations", middleware.JWTMiddleware(database.Database))
+	{
+		conversationRoute.GET("", controllers.ListConversations)
+		conversationRoute.POST("", middleware.RequireVerifiedEmail("message"), controllers.StartConversation)
+		conversationRoute.GET("/:id/messages", controllers.GetMessages)
+		conversationRoute.POST("/:id/messages", middleware.RequireVerifiedEmail("message"), controllers.SendMessage)
+		conversationRoute.POST("/:id/read", controllers.MarkConversationRead)
+	}
+
+	inviteRoute := api.Group("/invites", middleware.JWTMiddleware(database.Database))
+	{
+		inviteRoute.POST("", controllers.CreateInvite)
+		inviteRoute.GET("", controllers.ListInvites)
+		inviteRoute.DELETE("/:id", controllers.RevokeInvite)
+	}
+
+	adminRoute := api.Group("/admin", middleware.JWTMiddleware(database.Database))
+	{
+		adminRoute.GET("/settings", middleware.RequirePermission(model.PermissionManageSettings), controllers.ListSettings)
+		adminRoute.PATCH("/settings", middleware.RequirePermission(model.PermissionManageSettings), controllers.UpdateSettings)
+		adminRoute.GET("/lockouts", middleware.RequirePermission(model.PermissionManageSettings), controllers.ListLockouts)
+		adminRoute.DELETE("/lockouts/:key", middleware.RequirePermission(model.PermissionManageSettings), controllers.ClearLockout)
+		adminRoute.GET("/permissions", middleware.RequirePermission(model.PermissionManageRoles), controllers.ListPermissions)
+		adminRoute.GET("/roles", middleware.RequirePermission(model.PermissionManageRoles), controllers.ListRoles)
+		adminRoute.POST("/roles", middleware.RequirePermission(model.PermissionManageRoles), controllers.CreateRole)
+		adminRoute.PATCH("/roles/:id", middleware.RequirePermission(model.PermissionManageRoles), controllers.UpdateRole)
+		adminRoute.DELETE("/roles/:id", middleware.RequirePermission(model.PermissionManageRoles), controllers.DeleteRole)
+		adminRoute.GET("/users", middleware.RequirePermission(model.PermissionManageUsers), controllers.ListUsers)
+		adminRoute.GET("/users/:id/history", middleware.RequirePermission(model.PermissionManageUsers), controllers.GetModerationHistory)
+		adminRoute.PATCH("/users/:id/username", middleware.RequirePermission(model.PermissionManageUsers), controllers.ChangeUsername)
+		adminRoute.POST("/users/:id/password-reset", middleware.RequirePermission(model.PermissionManageUsers), controllers.ForcePasswordReset)
+		adminRoute.DELETE("/users/:id/sessions", middleware.RequirePermission(model.PermissionManageUsers), controllers.RevokeUserSessions)
+		adminRoute.POST("/users/:id/merge", middleware.RequirePermission(model.PermissionManageUsers), controllers.MergeUsers)
+		adminRoute.GET("/audit-logs", middleware.RequirePermission(model.PermissionManageUsers), controllers.ListAuditLogs)
+		adminRoute.PUT("/users/:id/role", middleware.RequirePermission(model.PermissionManageRoles), controllers.SetUserRole)
+		adminRoute.PUT("/users/:id/trust-level", middleware.RequirePermission(model.PermissionManageUsers), controllers.SetTrustLevel)
+		adminRoute.GET("/users/:id/export", middleware.RequirePermission(model.PermissionManageUsers), controllers.ExportUser)
+		adminRoute.DELETE("/users/:id", middleware.RequirePermission(model.PermissionManageUsers), controllers.DeleteUser)
+		adminRoute.POST("/avatars", middleware.RequirePermission(model.PermissionManageAvatars), controllers.CreateAvatar)
+		adminRoute.PUT("/avatars/:id", middleware.RequirePermission(model.PermissionManageAvatars), controllers.UpdateAvatar)
+		adminRoute.DELETE("/avatars/:id", middleware.RequirePermission(model.PermissionManageAvatars), controllers.DeleteAvatar)
+		adminRoute.GET("/role-assignments", middleware.RequirePermission(model.PermissionManageRoles), controllers.ListRoleAssignments)
+		adminRoute.POST("/role-assignments", middleware.RequirePermission(model.PermissionManageRoles), controllers.AssignRole)
+		adminRoute.DELETE("/role-assignments/:id", middleware.RequirePermission(model.PermissionManageRoles), controllers.UnassignRole)
+		adminRoute.GET("/bans", middleware.RequirePermission(model.PermissionBanUsers), controllers.ListBans)
+		adminRoute.POST("/bans", middleware.RequirePermission(model.PermissionBanUsers), controllers.IssueBan)
+		adminRoute.DELETE("/bans/:id", middleware.RequirePermission(model.PermissionBanUsers), controllers.LiftBan)
+		adminRoute.GET("/registrations", middleware.RequirePermission(model.PermissionManageRegistrations), controllers.ListPendingRegistrations)
+		adminRoute.POST("/registrations/:id/approve", middleware.RequirePermission(model.PermissionManageRegistrations), controllers.ApproveRegistration)
+		adminRoute.POST("/registrations/:id/reject", middleware.RequirePermission(model.PermissionManageRegistrations), controllers.RejectRegistration)
 	}
 
-	r.GET("/ws", websocket.WsHandler)
+	r.GET("/ws", middleware.WebSocketAuth(database.Database), websocket.WsHandler)
 
 	r.Run(":" + os.Getenv("APP_PORT"))
 }
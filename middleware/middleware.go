@@ -1,22 +1,36 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"onichan/model"
+	"onichan/services"
 	"onichan/utils"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	"gorm.io/gorm"
 )
 
+// CORSMiddleware allows credentialed requests from CORS_ALLOWED_ORIGINS. When
+// no origins are configured any origin may call the API with bearer tokens,
+// but not with cookies.
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		allowedOrigin := "*"
+		origin := c.GetHeader("Origin")
 
-		c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		if utils.IsAllowedOrigin(origin) {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			c.Writer.Header().Add("Vary", "Origin")
+		} else if len(utils.AllowedOrigins()) == 0 {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, X-Auth-Mode, X-Challenge, X-Challenge-Solution, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -28,10 +42,61 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
-func JWTMiddleware(db *gorm.DB) gin.HandlerFunc {
+// CSRFMiddleware protects cookie-authenticated requests with a double-submit
+// token: state-changing requests that carry session cookies must repeat the
+// csrf_token cookie in the X-CSRF-Token header. Requests with an
+// Authorization header cannot be forged by another site and are skipped.
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("Authorization") != "" || !hasSessionCookie(c) {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(utils.CSRFCookie)
+		header := c.GetHeader(utils.CSRFHeader)
+		if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid CSRF token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasSessionCookie(c *gin.Context) bool {
+	for _, name := range []string{utils.AccessTokenCookie, utils.RefreshTokenCookie} {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTMiddleware authenticates the request with either a session access token,
+// from the Authorization header or the access_token cookie, or a personal API
+// token. API tokens are only accepted on routes that list
+// the scopes they need, and the token must carry all of them.
+func JWTMiddleware(db *gorm.DB, scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			if cookie, err := c.Cookie(utils.AccessTokenCookie); err == nil && cookie != "" {
+				if !authenticate(c, db, cookie, utils.AudienceAccess) {
+					return
+				}
+				c.Set("auth_method", "cookie")
+				c.Next()
+				return
+			}
+
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
 			return
@@ -44,36 +109,310 @@ func JWTMiddleware(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		token, err := utils.ValidateJWT(parts[1])
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		if strings.HasPrefix(parts[1], model.APITokenPrefix) {
+			if !authenticateAPIToken(c, db, parts[1], scopes) {
+				return
+			}
+		} else if !authenticate(c, db, parts[1], utils.AudienceAccess) {
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		userID := claims["user_id"]
+		c.Next()
+	}
+}
+
+// OptionalAuth identifies the caller on public routes that personalize their
+// response. Anonymous requests, API tokens and invalid or expired tokens,
+// such as a stale cookie, pass through without a user.
+func OptionalAuth(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		viaCookie := false
+		if tokenString == "" {
+			tokenString, _ = c.Cookie(utils.AccessTokenCookie)
+			viaCookie = true
+		}
+		if tokenString == "" || strings.HasPrefix(tokenString, model.APITokenPrefix) {
+			c.Next()
+			return
+		}
 
-		var role string
-		err = db.Raw("SELECT role FROM users WHERE id = ?", userID).Scan(&role).Error
+		session, userID, err := findSession(db, tokenString, utils.AudienceAccess)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query user role"})
+			c.Next()
+			return
+		}
+		if !startAuthenticated(c, db, session, userID) {
+			return
+		}
+		if viaCookie {
+			c.Set("auth_method", "cookie")
+		}
+
+		c.Next()
+	}
+}
+
+// WebSocketAuth authenticates the websocket handshake, where browsers cannot
+// set an Authorization header. It takes either the access_token cookie, from
+// an allowed origin only, or a short-lived token from /auth/ws-token in the
+// token query parameter, so access tokens stay out of URLs and logs.
+func WebSocketAuth(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.Query("token")
+		if tokenString != "" {
+			if !authenticate(c, db, tokenString, utils.AudienceWebSocket) {
+				return
+			}
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(utils.AccessTokenCookie)
+		if err != nil || cookie == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+			c.Abort()
+			return
+		}
+
+		// Browsers send cookies on cross-site websocket handshakes, so the
+		// origin check stands in for CSRF protection here.
+		if !utils.IsAllowedOrigin(c.GetHeader("Origin")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
+			c.Abort()
+			return
+		}
+
+		if !authenticate(c, db, cookie, utils.AudienceAccess) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticate validates a token for the given audience and its backing
+// session and stores the caller identity in the context. It aborts the request and returns false
+// on failure.
+var errInvalidToken = errors.New("Invalid or expired token")
+var errSessionRevoked = errors.New("Session has expired or been revoked")
+
+// findSession returns the active session the token belongs to and the
+// token's user ID.
+func findSession(db *gorm.DB, tokenString, audience string) (model.Session, interface{}, error) {
+	var session model.Session
+
+	token, err := utils.ValidateJWT(tokenString, audience)
+	if err != nil || !token.Valid {
+		return session, nil, errInvalidToken
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userID := claims["user_id"]
+	sessionID, ok := claims["session_id"].(float64)
+	if !ok {
+		return session, nil, errInvalidToken
+	}
+
+	if err := db.
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", uint(sessionID), userID, time.Now()).
+		First(&session).Error; err != nil {
+		return session, nil, errSessionRevoked
+	}
+	return session, userID, nil
+}
+
+func authenticate(c *gin.Context, db *gorm.DB, tokenString, audience string) bool {
+	session, userID, err := findSession(db, tokenString, audience)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+	return startAuthenticated(c, db, session, userID)
+}
+
+// startAuthenticated records the session's use and loads its user into the
+// context.
+func startAuthenticated(c *gin.Context, db *gorm.DB, session model.Session, userID interface{}) bool {
+	// Last-seen only needs minute precision, so avoid a write on every request.
+	if time.Since(session.LastSeenAt) > time.Minute {
+		db.Model(&session).Updates(map[string]interface{}{
+			"last_seen_at": time.Now(),
+			"ip_address":   c.ClientIP(),
+		})
+	}
+
+	c.Set("session_id", session.ID)
+	c.Set("auth_method", "session")
+
+	return loadUser(c, db, userID)
+}
+
+func authenticateAPIToken(c *gin.Context, db *gorm.DB, tokenString string, scopes []string) bool {
+	var apiToken model.APIToken
+	if err := db.
+		Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", utils.HashToken(tokenString), time.Now()).
+		First(&apiToken).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+
+	if len(scopes) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API token"})
+		c.Abort()
+		return false
+	}
+
+	for _, scope := range scopes {
+		if !apiToken.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API token is missing the " + scope + " scope"})
+			c.Abort()
+			return false
+		}
+	}
+
+	if apiToken.LastUsedAt == nil || time.Since(*apiToken.LastUsedAt) > time.Minute {
+		db.Model(&apiToken).Update("last_used_at", time.Now())
+	}
+
+	c.Set("auth_method", "api_token")
+	c.Set("api_token_id", apiToken.ID)
+
+	return loadUser(c, db, float64(apiToken.UserID))
+}
+
+// loadUser stores the authenticated user's identity and role in the context,
+// turning away banned users.
+// user_id is kept as a float64, the type JWT claims decode to, since handlers
+// read it that way.
+// readOnlyBanExempt lists the routes that stay open to read-only banned
+// users besides reads, so they can still end their own sessions.
+var readOnlyBanExempt = map[string]bool{
+	"/api/auth/logout":     true,
+	"/api/auth/logout-all": true,
+	"/api/auth/tokens/:id": true,
+}
+
+func loadUser(c *gin.Context, db *gorm.DB, userID interface{}) bool {
+	var user struct {
+		Role             string
+		EmailVerified    bool
+		TwoFactorEnabled bool
+		TrustLevel       int
+	}
+	err := db.Raw(`SELECT role, trust_level, email_verified_at IS NOT NULL AS email_verified,
+		EXISTS (SELECT 1 FROM two_factors WHERE two_factors.user_id = users.id AND confirmed_at IS NOT NULL AND deleted_at IS NULL) AS two_factor_enabled
+		FROM users WHERE id = ?`, userID).Scan(&user).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query user role"})
+		c.Abort()
+		return false
+	}
+
+	if ban, err := services.GetAccountBan(uint(userID.(float64))); err == nil {
+		readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || readOnlyBanExempt[c.FullPath()]
+		if ban.Scope == model.BanScopeFull || !readOnly {
+			c.JSON(http.StatusForbidden, gin.H{"error": ban.Message(), "ban": ban.Notice()})
+			c.Abort()
+			return false
+		}
+	}
+
+	c.Set("user_id", userID)
+	c.Set("role", user.Role)
+	c.Set("email_verified", user.EmailVerified)
+	c.Set("two_factor_enabled", user.TwoFactorEnabled)
+	c.Set("trust_level", user.TrustLevel)
+
+	return true
+}
+
+// RequirePermission lets the request through when the user holds every
+// given permission, either globally or for at least one category. Handlers
+// acting on a single category check the permission for that category again.
+// Staff must have two-factor authentication enabled when the
+// require_admin_2fa setting is on.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := uint(c.MustGet("user_id").(float64))
+		role := c.GetString("role")
+
+		for _, permission := range permissions {
+			if !services.HasPermissionAnywhere(userID, role, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied. Missing permission: " + permission})
+				c.Abort()
+				return
+			}
+		}
+
+		if !c.GetBool("two_factor_enabled") && services.GetBoolSetting(services.SettingRequireAdminTwoFactor) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for staff"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireChallenge makes requests for the action carry a solved
+// proof-of-work challenge in the X-Challenge and X-Challenge-Solution headers
+// while the action's challenge setting is on. Trusted users skip it. Use it
+// after JWTMiddleware on authenticated routes.
+func RequireChallenge(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !services.ChallengeRequired(action) {
+			c.Next()
+			return
+		}
+
+		var userID *uint
+		if value, ok := c.Get("user_id"); ok {
+			id := uint(value.(float64))
+			userID = &id
+		}
+
+		if err := services.CheckChallenge(action, userID, c.GetString("role"), c.GetHeader(services.ChallengeHeader), c.GetHeader(services.ChallengeSolutionHeader)); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "challenge_required": true, "action": action})
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", userID)
-		c.Set("role", role)
+		c.Set("challenge_passed", true)
+		c.Next()
+	}
+}
+
+// RequireTrustLevel lets the request through when the user has at least the
+// trust level configured by the given setting. Staff always pass.
+func RequireTrustLevel(setting string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := uint(c.MustGet("user_id").(float64))
+		if !services.MeetsTrustLevel(userID, c.GetString("role"), c.GetInt("trust_level"), setting) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your trust level is too low for this action", "required_trust_level": services.GetIntSetting(setting)})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
-func AdminOnly() gin.HandlerFunc {
+// RequireVerifiedEmail blocks users with an unverified email from the given
+// action when it is listed in UNVERIFIED_RESTRICTIONS.
+func RequireVerifiedEmail(action string) gin.HandlerFunc {
+	restricted := false
+	for _, restriction := range strings.Split(os.Getenv("UNVERIFIED_RESTRICTIONS"), ",") {
+		if strings.TrimSpace(restriction) == action {
+			restricted = true
+		}
+	}
+
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied. Admins only."})
+		if restricted && !c.GetBool("email_verified") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first"})
 			c.Abort()
 			return
 		}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 	"onichan/utils"
+
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -15,14 +18,63 @@ func main() {
 func loadDatabase() {
 	database.Connect()
 
+	// Accounts that predate email verification are treated as verified.
+	// Accounts that are unverified when UnverifiedSince is added are left
+	// alone, since they may be established accounts that changed their email.
+	backfillVerified := !database.Database.Migrator().HasColumn(&model.User{}, "EmailVerifiedAt")
+	backfillReputation := !database.Database.Migrator().HasColumn(&model.User{}, "Reputation")
 	database.Database.AutoMigrate(&model.User{})
+	if backfillVerified {
+		database.Database.Model(&model.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+	}
 	database.Database.AutoMigrate(&model.Notification{})
+	// Posts removed before removals were recorded were all removed by
+	// moderators resolving reports.
+	backfillRemoved := !database.Database.Migrator().HasColumn(&model.Post{}, "RemovedAt")
 	database.Database.AutoMigrate(&model.Post{})
+	if backfillRemoved {
+		database.Database.Model(&model.Post{}).Where("is_deleted = ?", true).Update("removed_at", gorm.Expr("updated_at"))
+	}
 	database.Database.AutoMigrate(&model.PostReaction{})
 	database.Database.AutoMigrate(&model.Reaction{})
 	database.Database.AutoMigrate(&model.Category{})
 	database.Database.AutoMigrate(&model.Avatar{})
+	database.Database.AutoMigrate(&model.Session{})
+	database.Database.AutoMigrate(&model.RefreshToken{})
+	database.Database.AutoMigrate(&model.UserToken{})
+	database.Database.AutoMigrate(&model.TwoFactor{})
+	database.Database.AutoMigrate(&model.RecoveryCode{})
+	database.Database.AutoMigrate(&model.Setting{})
+	database.Database.AutoMigrate(&model.Identity{})
+	database.Database.AutoMigrate(&model.OAuthState{})
+	database.Database.AutoMigrate(&model.LoginThrottle{})
+	database.Database.AutoMigrate(&model.APIToken{})
+	database.Database.AutoMigrate(&model.Role{})
+	database.Database.AutoMigrate(&model.RoleAssignment{})
+	database.Database.AutoMigrate(&model.Ban{})
+	database.Database.AutoMigrate(&model.Invite{})
+	database.Database.AutoMigrate(&model.Follow{})
+	database.Database.AutoMigrate(&model.Block{})
+	database.Database.AutoMigrate(&model.PostView{})
+	database.Database.AutoMigrate(&model.Conversation{})
+	database.Database.AutoMigrate(&model.ConversationParticipant{})
+	database.Database.AutoMigrate(&model.Message{})
 	database.Database.AutoMigrate(&model.Report{})
+	database.Database.AutoMigrate(&model.UserPreferences{})
+	backfillActorUsername := !database.Database.Migrator().HasColumn(&model.AuditLog{}, "ActorUsername")
+	database.Database.AutoMigrate(&model.AuditLog{})
+	if backfillActorUsername {
+		database.Database.Exec("UPDATE audit_logs SET actor_username = users.username FROM users WHERE users.id = audit_logs.actor_id")
+	}
+	database.Database.AutoMigrate(&model.EmailDebounce{})
+	if err := services.SeedRoles(); err != nil {
+		fmt.Println("Error seeding roles:", err)
+	}
+	if backfillReputation {
+		if err := services.RecalculateReputation(); err != nil {
+			fmt.Println("Error calculating reputation:", err)
+		}
+	}
 
 	fmt.Println("Migration completed successfully")
 }
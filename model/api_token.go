@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const APITokenPrefix = "oni_"
+
+// Scopes that can be granted to personal API tokens.
+const (
+	ScopePostsWrite         = "posts:write"
+	ScopeReactionsWrite     = "reactions:write"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
+	ScopeReportsWrite       = "reports:write"
+	ScopeUploadsWrite       = "uploads:write"
+	ScopeAdminReports       = "admin:reports"
+	ScopeAdminCategories    = "admin:categories"
+	ScopeAdminReactions     = "admin:reactions"
+)
+
+var APITokenScopes = []string{
+	ScopePostsWrite,
+	ScopeReactionsWrite,
+	ScopeNotificationsRead,
+	ScopeNotificationsWrite,
+	ScopeReportsWrite,
+	ScopeUploadsWrite,
+	ScopeAdminReports,
+	ScopeAdminCategories,
+	ScopeAdminReactions,
+}
+
+// APIToken is a personal access token for scripts and bots. Only the SHA-256
+// hash of the token is stored; Prefix keeps enough of it to tell tokens apart.
+type APIToken struct {
+	gorm.Model
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Name       string     `gorm:"size:63;not null" json:"name"`
+	Prefix     string     `gorm:"size:15" json:"prefix"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"type:text;serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+func (t APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Actions recorded in the audit log.
+const (
+	AuditActionChangeRole     = "change_role"
+	AuditActionSetTrustLevel  = "set_trust_level"
+	AuditActionChangeUsername = "change_username"
+	AuditActionPasswordReset  = "force_password_reset"
+	AuditActionRevokeSessions = "revoke_sessions"
+	AuditActionMergeUsers     = "merge_users"
+	AuditActionExportUser     = "export_user"
+	AuditActionDeleteUser     = "delete_user"
+)
+
+// AuditLog records an action an admin took on a user account. Details holds
+// action specific values, such as the old and new username. Entries outlive
+// the accounts they mention: ActorID is cleared when the actor is deleted,
+// and ActorUsername keeps who it was.
+type AuditLog struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	ActorID       *uint                  `gorm:"index" json:"actor_id"`
+	Actor         *User                  `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	ActorUsername string                 `gorm:"size:31" json:"actor_username"`
+	Action        string                 `gorm:"size:31;index;not null" json:"action"`
+	TargetUserID  *uint                  `gorm:"index" json:"target_user_id"`
+	TargetUser    *User                  `gorm:"foreignKey:TargetUserID" json:"target_user,omitempty"`
+	Details       map[string]interface{} `gorm:"type:text;serializer:json" json:"details"`
+	CreatedAt     time.Time              `gorm:"index" json:"created_at"`
+}
+
+// ModerationHistory is what moderators did about a user and what was
+// reported about them.
+type ModerationHistory struct {
+	Bans         []Ban      `json:"bans"`
+	RemovedPosts []Post     `json:"removed_posts"`
+	Reports      []Report   `json:"reports"`
+	AuditLogs    []AuditLog `json:"audit_logs"`
+}
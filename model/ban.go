@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// BanScopeFull locks the user out of their account.
+	BanScopeFull = "full"
+	// BanScopeReadOnly lets the user sign in and read, but not write.
+	BanScopeReadOnly = "read_only"
+	// BanScopeCategory stops the user from posting and reacting in one category.
+	BanScopeCategory = "category"
+)
+
+// Ban restricts a user until ExpiresAt, or forever when it is nil. A ban is
+// active until it expires or is lifted.
+type Ban struct {
+	gorm.Model
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"user"`
+	Reason     string     `gorm:"type:text" json:"reason"`
+	Scope      string     `gorm:"size:15;not null" json:"scope"`
+	CategoryID *uint      `json:"category_id"`
+	IssuedByID uint       `json:"issued_by_id"`
+	IssuedBy   User       `gorm:"foreignKey:IssuedByID" json:"issued_by"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LiftedAt   *time.Time `json:"lifted_at"`
+	LiftedByID *uint      `json:"lifted_by_id"`
+}
+
+func (b Ban) IsActive() bool {
+	return b.LiftedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(time.Now()))
+}
+
+// BanNotice is what the banned user is told about their ban.
+type BanNotice struct {
+	Reason     string     `json:"reason"`
+	Scope      string     `json:"scope"`
+	CategoryID *uint      `json:"category_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+func (b Ban) Notice() BanNotice {
+	return BanNotice{
+		Reason:     b.Reason,
+		Scope:      b.Scope,
+		CategoryID: b.CategoryID,
+		ExpiresAt:  b.ExpiresAt,
+	}
+}
+
+func (b Ban) Message() string {
+	subject := "Your account is"
+	if b.Scope == BanScopeCategory {
+		subject = "You are"
+	}
+
+	verb := " banned"
+	switch b.Scope {
+	case BanScopeReadOnly:
+		verb = " restricted to read-only access"
+	case BanScopeCategory:
+		verb = " banned from this category"
+	}
+
+	if b.ExpiresAt == nil {
+		return subject + verb + " permanently"
+	}
+	return subject + verb + " until " + b.ExpiresAt.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "gorm.io/gorm"
+
+const (
+	// BlockLevelMute hides the target's posts and notifications from the user.
+	BlockLevelMute = "mute"
+	// BlockLevelBlock also keeps the target from replying or reacting to the
+	// user's posts.
+	BlockLevelBlock = "block"
+)
+
+// Block records that UserID muted or blocked TargetID. A user has at most one
+// of the two for each target.
+type Block struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;uniqueIndex:block_index" json:"user_id"`
+	TargetID uint   `gorm:"not null;uniqueIndex:block_index;index" json:"target_id"`
+	Target   User   `gorm:"foreignKey:TargetID" json:"target"`
+	Level    string `gorm:"size:7;not null" json:"level"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Conversation is a private conversation between two or more users.
+type Conversation struct {
+	gorm.Model
+	Title         *string                   `gorm:"size:127" json:"title"`
+	CreatedByID   uint                      `json:"created_by_id"`
+	LastMessageAt time.Time                 `gorm:"index" json:"last_message_at"`
+	Participants  []ConversationParticipant `json:"participants"`
+	UnreadCount   int64                     `gorm:"-" json:"unread_count"`
+}
+
+// ConversationParticipant is a member of a conversation. Messages sent after
+// LastReadAt by other members are unread.
+type ConversationParticipant struct {
+	ID             uint       `gorm:"primarykey" json:"-"`
+	ConversationID uint       `gorm:"uniqueIndex:idx_conversation_participant;not null" json:"-"`
+	UserID         uint       `gorm:"uniqueIndex:idx_conversation_participant;index;not null" json:"user_id"`
+	User           User       `gorm:"foreignKey:UserID" json:"user"`
+	LastReadAt     *time.Time `json:"last_read_at"`
+	CreatedAt      time.Time  `json:"joined_at"`
+}
+
+type Message struct {
+	gorm.Model
+	ConversationID uint   `gorm:"index;not null" json:"conversation_id"`
+	UserID         uint   `gorm:"index;not null" json:"user_id"`
+	User           User   `gorm:"foreignKey:UserID" json:"user"`
+	Content        string `gorm:"type:text;not null" json:"content"`
+}
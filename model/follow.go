@@ -0,0 +1,21 @@
+package model
+
+import "gorm.io/gorm"
+
+// NotificationTypeFollowedThread notifies followers when a user they follow
+// starts a thread.
+const NotificationTypeFollowedThread = "followed_thread"
+
+// Follow subscribes FollowerID to either a user or a category; exactly one of
+// FollowingID and CategoryID is set. Notify asks for a notification when a
+// followed user starts a thread.
+type Follow struct {
+	gorm.Model
+	FollowerID  uint      `gorm:"not null;uniqueIndex:follow_user_index,where:following_id IS NOT NULL;uniqueIndex:follow_category_index,where:category_id IS NOT NULL" json:"follower_id"`
+	Follower    *User     `gorm:"foreignKey:FollowerID" json:"follower,omitempty"`
+	FollowingID *uint     `gorm:"index;uniqueIndex:follow_user_index,where:following_id IS NOT NULL" json:"following_id"`
+	Following   *User     `gorm:"foreignKey:FollowingID" json:"following,omitempty"`
+	CategoryID  *uint     `gorm:"index;uniqueIndex:follow_category_index,where:category_id IS NOT NULL" json:"category_id"`
+	Category    *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Notify      bool      `gorm:"default:false" json:"notify"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Identity links an account at an external identity provider to a user.
+type Identity struct {
+	gorm.Model
+	UserID   uint   `gorm:"index" json:"user_id"`
+	Provider string `gorm:"size:63;not null;uniqueIndex:provider_subject_index" json:"provider"`
+	Subject  string `gorm:"size:255;not null;uniqueIndex:provider_subject_index" json:"-"`
+	Email    string `gorm:"size:255" json:"email"`
+}
+
+// OAuthState remembers an authorization request between the redirect to the
+// provider and the callback. LinkUserID is set when a logged-in user links a
+// new provider rather than signing in.
+type OAuthState struct {
+	gorm.Model
+	StateHash    string `gorm:"size:64;uniqueIndex"`
+	Provider     string `gorm:"size:63"`
+	CodeVerifier string `gorm:"size:127"`
+	Nonce        string `gorm:"size:127"`
+	BindingHash  string `gorm:"size:64"` // the browser that started the flow
+	LinkUserID   *uint
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invite lets people register while registration is invite-only. Only the
+// SHA-256 hash of the code is stored. An invite with an Email can only be
+// used to register that address.
+type Invite struct {
+	gorm.Model
+	CodeHash    string     `gorm:"size:64;uniqueIndex" json:"-"`
+	Prefix      string     `gorm:"size:15" json:"prefix"`
+	CreatedByID uint       `gorm:"index" json:"created_by_id"`
+	Email       *string    `gorm:"size:255" json:"email"`
+	MaxUses     int        `gorm:"not null;default:1" json:"max_uses"`
+	Uses        int        `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// UsableBy reports whether the invite can still be used to register email.
+func (i Invite) UsableBy(email string) bool {
+	if i.RevokedAt != nil || i.Uses >= i.MaxUses {
+		return false
+	}
+	if i.ExpiresAt != nil && i.ExpiresAt.Before(time.Now()) {
+		return false
+	}
+	return i.Email == nil || strings.EqualFold(*i.Email, email)
+}
@@ -17,3 +17,12 @@ type Notification struct {
 	CreatedAt        time.Time `gorm:"index:user_notifications_index,priority:2" json:"created_at"`
 	IsRead           bool      `gorm:"default:false;index:user_notifications_index,priority:3" json:"is_read"`
 }
+
+// EmailDebounce records when a user was last emailed about one subject, such
+// as a conversation or a thread, so activity there sends at most one email
+// per interval.
+type EmailDebounce struct {
+	UserID uint      `gorm:"primaryKey;autoIncrement:false"`
+	Key    string    `gorm:"primaryKey;size:63"`
+	SentAt time.Time `gorm:"not null"`
+}
@@ -11,13 +11,21 @@ type Category struct {
 	Name        string  `gorm:"size:63;not null;unique" json:"name"`
 	Description string  `gorm:"size:255;not null" json:"description"`
 	ImageURL    *string `gorm:"size:255" json:"image_url"`
-	Posts       []Post  `gorm:"foreignKey:CategoryID"`
+	// RequireChallenge asks for a proof-of-work challenge on new posts in
+	// this category even when the challenge_posts setting is off.
+	RequireChallenge bool `gorm:"default:false" json:"require_challenge"`
+	// NSFW categories are left out for users who hide NSFW content.
+	NSFW  bool   `gorm:"default:false" json:"nsfw"`
+	Posts []Post `gorm:"foreignKey:CategoryID"`
 }
 
 type Reaction struct {
 	gorm.Model
 	Name  string `gorm:"size:31;not null;unique" json:"name"`
 	Emoji string `gorm:"type:varchar(15)" json:"emoji"`
+	// Weight is added to the reputation of the author of a post that gets
+	// this reaction.
+	Weight int `gorm:"not null;default:0" json:"weight"`
 }
 
 type PostReaction struct {
@@ -54,4 +62,15 @@ type Post struct {
 	UserReactions []PostReactionCount `gorm:"-" json:"user_reactions"`
 	Page          int                 `gorm:"-" json:"page"`
 	IsDeleted     bool                `gorm:"default:false" json:"is_deleted"`
+	RemovedAt     *time.Time          `json:"removed_at"` // set when a moderator removed the post
+	// Collapsed is set when the viewer muted or blocked the author.
+	Collapsed bool `gorm:"-" json:"collapsed"`
+}
+
+// PostView records that a user opened a thread, counted as threads read for
+// their trust level.
+type PostView struct {
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	PostID    uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
 }
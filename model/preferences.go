@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Thread orders users can pick for thread lists.
+const (
+	ThreadSortActivity = "activity"
+	ThreadSortNewest   = "newest"
+	ThreadSortOldest   = "oldest"
+)
+
+// UserPreferences are the per-user settings. A PageSize of 0 uses the
+// forum's PAGE_SIZE.
+type UserPreferences struct {
+	UserID                uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	PageSize              int       `gorm:"not null" json:"page_size"`
+	ThreadSort            string    `gorm:"size:15;not null" json:"thread_sort"`
+	Timezone              string    `gorm:"size:63;not null" json:"timezone"`
+	Locale                string    `gorm:"size:15;not null" json:"locale"`
+	EmailOnReply          bool      `gorm:"not null" json:"email_on_reply"`
+	EmailOnFollowedThread bool      `gorm:"not null" json:"email_on_followed_thread"`
+	EmailOnMessage        bool      `gorm:"not null" json:"email_on_message"`
+	HideNSFW              bool      `gorm:"not null" json:"hide_nsfw"`
+	MutedCategoryIDs      []uint    `gorm:"type:text;serializer:json" json:"muted_category_ids"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
@@ -2,12 +2,18 @@ package model
 
 import "gorm.io/gorm"
 
+// Report flags either a post or a private message for moderators.
 type Report struct {
 	gorm.Model
 
-	PostID   uint `json:"post_id"`
-	Post     Post `gorm:"foreignKey:PostID" json:"post"`
-	UserID   uint `json:"user_id"`
-	User     User `gorm:"foreignKey:UserID" json:"user"`
-	Resolved bool `gorm:"default:false" json:"resolved"`
+	PostID    *uint    `json:"post_id"`
+	Post      *Post    `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	MessageID *uint    `json:"message_id"`
+	Message   *Message `gorm:"foreignKey:MessageID" json:"message,omitempty"`
+	UserID    uint     `json:"user_id"`
+	User      User     `gorm:"foreignKey:UserID" json:"user"`
+	Resolved  bool     `gorm:"default:false" json:"resolved"`
+	// Weight is 2 for reports from users with the trust level set by
+	// trust_level_weighted_flags, and 1 otherwise.
+	Weight int `gorm:"not null;default:1" json:"weight"`
 }
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// Permissions that can be granted to roles.
+const (
+	PermissionManageCategories    = "manage_categories"
+	PermissionResolveReports      = "resolve_reports"
+	PermissionDeleteAnyPost       = "delete_any_post"
+	PermissionEditAnyPost         = "edit_any_post"
+	PermissionManageReactions     = "manage_reactions"
+	PermissionBanUsers            = "ban_users"
+	PermissionManageRoles         = "manage_roles"
+	PermissionManageSettings      = "manage_settings"
+	PermissionManageRegistrations = "manage_registrations"
+	PermissionManageUsers         = "manage_users"
+	PermissionManageAvatars       = "manage_avatars"
+
+	// PermissionAll grants every permission, including ones added later.
+	PermissionAll = "*"
+)
+
+var Permissions = []string{
+	PermissionManageCategories,
+	PermissionResolveReports,
+	PermissionDeleteAnyPost,
+	PermissionEditAnyPost,
+	PermissionManageReactions,
+	PermissionBanUsers,
+	PermissionManageRoles,
+	PermissionManageSettings,
+	PermissionManageRegistrations,
+	PermissionManageUsers,
+	PermissionManageAvatars,
+}
+
+// CategoryPermissions are the permissions that can be limited to a category
+// through a RoleAssignment. Other permissions only apply globally.
+var CategoryPermissions = []string{
+	PermissionResolveReports,
+	PermissionDeleteAnyPost,
+	PermissionEditAnyPost,
+	PermissionBanUsers,
+}
+
+// Role is a named set of permissions. Every user has one global role in
+// User.Role and may additionally hold roles for single categories.
+type Role struct {
+	gorm.Model
+	Name        string   `gorm:"size:31;not null;uniqueIndex" json:"name"`
+	Description string   `gorm:"size:255" json:"description"`
+	Permissions []string `gorm:"type:text;serializer:json" json:"permissions"`
+	IsSystem    bool     `gorm:"default:false" json:"is_system"`
+}
+
+func (r Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission || p == PermissionAll {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAssignment grants a role to a user within a single category, such as a
+// moderator for one board.
+type RoleAssignment struct {
+	gorm.Model
+	UserID       uint     `gorm:"not null;uniqueIndex:role_assignment_index" json:"user_id"`
+	User         User     `gorm:"foreignKey:UserID" json:"-"`
+	RoleID       uint     `gorm:"not null;uniqueIndex:role_assignment_index" json:"role_id"`
+	Role         Role     `gorm:"foreignKey:RoleID" json:"role"`
+	CategoryID   uint     `gorm:"not null;uniqueIndex:role_assignment_index" json:"category_id"`
+	Category     Category `gorm:"foreignKey:CategoryID" json:"category"`
+	AssignedByID uint     `json:"assigned_by_id"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Session struct {
+	gorm.Model
+	UserID     uint       `gorm:"index" json:"user_id"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IPAddress  string     `gorm:"size:63" json:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"-"`
+}
+
+type RefreshToken struct {
+	gorm.Model
+	SessionID uint    `gorm:"index"`
+	Session   Session `gorm:"foreignKey:SessionID"`
+	TokenHash string  `gorm:"size:64;uniqueIndex"`
+	UsedAt    *time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Setting is a runtime configuration value that admins can change without a
+// restart.
+type Setting struct {
+	Key       string    `gorm:"primaryKey;size:63" json:"key"`
+	Value     string    `gorm:"size:255;not null" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
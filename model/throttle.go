@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LoginThrottle counts recent failed attempts for one throttling key, such as
+// an account or a client IP.
+type LoginThrottle struct {
+	Key           string    `gorm:"primaryKey;size:255" json:"key"`
+	Failures      int       `gorm:"not null;default:0" json:"failures"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastFailureAt time.Time `gorm:"index" json:"last_failure_at"`
+}
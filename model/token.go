@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeMagicLink         = "magic_link"
+)
+
+// UserToken is a single-use secret mailed to a user. Only the SHA-256 hash of
+// the token is stored. Tokens with a NonceHash can only be redeemed together
+// with the nonce, which binds them to the browser that requested them.
+type UserToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	Purpose   string `gorm:"size:31;index"`
+	TokenHash string `gorm:"size:64;uniqueIndex"`
+	NonceHash string `gorm:"size:64"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
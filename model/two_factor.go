@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactor holds a user's TOTP secret. Two-factor authentication is only
+// active once ConfirmedAt is set.
+type TwoFactor struct {
+	gorm.Model
+	UserID       uint       `gorm:"uniqueIndex" json:"user_id"`
+	Secret       string     `gorm:"size:63;not null" json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	LastUsedStep int64      `json:"-"`
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"index"`
+	CodeHash string `gorm:"size:64;uniqueIndex"`
+	UsedAt   *time.Time
+}
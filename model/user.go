@@ -1,14 +1,114 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+const (
+	UserStatusActive = "active"
+	// UserStatusPending accounts wait for an admin to approve their
+	// registration and cannot sign in yet.
+	UserStatusPending = "pending"
+	// UserStatusDeleted marks an account that was deleted and anonymized. The
+	// row is kept so the user's posts stay in their threads.
+	UserStatusDeleted = "deleted"
+)
+
+// Trust levels are computed from a user's activity and unlock capabilities.
+// TrustLevelLeader is only granted by admins.
+const (
+	TrustLevelNew = iota
+	TrustLevelBasic
+	TrustLevelMember
+	TrustLevelRegular
+	TrustLevelLeader
+)
+
+// User is embedded in posts, notifications and other responses seen by
+// everyone, so the email is never serialized; use Account for the owner.
 type User struct {
 	gorm.Model
-	Username     string  `gorm:"size:31;not null;uniqueIndex" json:"username"`
-	Email        string  `gorm:"size:255;not null;uniqueIndex" json:"email"`
-	PasswordHash string  `gorm:"size:255;not null" json:"-"`
-	Salt         string  `gorm:"size:255;not null" json:"-"`
-	AvatarURL    *string `gorm:"size:255" json:"avatar_url"`
-	Bio          *string `gorm:"type:text" json:"bio"`
-	Role         string  `gorm:"size:31;default:'user'" json:"role"`
+	Username         string     `gorm:"size:31;not null;uniqueIndex" json:"username"`
+	Email            string     `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	PasswordHash     string     `gorm:"size:255;not null" json:"-"`
+	Salt             string     `gorm:"size:255;not null" json:"-"` // only used by legacy bcrypt hashes
+	AvatarURL        *string    `gorm:"size:255" json:"avatar_url"`
+	Bio              *string    `gorm:"type:text" json:"bio"`
+	DisplayName      *string    `gorm:"size:63" json:"display_name"`
+	Signature        *string    `gorm:"size:255" json:"signature"`
+	Location         *string    `gorm:"size:63" json:"location"`
+	Links            []string   `gorm:"type:text;serializer:json" json:"links"`
+	Reputation       int        `gorm:"not null;default:0" json:"reputation"`
+	TrustLevel       int        `gorm:"not null;default:0" json:"trust_level"`
+	TrustLevelLocked bool       `gorm:"default:false" json:"trust_level_locked"` // set when an admin pinned the level
+	Role             string     `gorm:"size:31;default:'user'" json:"role"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	Status           string     `gorm:"size:15;default:'active'" json:"status"`
+	InvitedByID      *uint      `json:"invited_by_id"`
+	// DeletionScheduledAt is when a requested account deletion takes effect.
+	DeletionScheduledAt *time.Time `gorm:"index" json:"-"`
+	// UnverifiedSince is set while a new account has never verified its
+	// email. Unlike EmailVerifiedAt it is not reset by email changes.
+	UnverifiedSince *time.Time `gorm:"index" json:"-"`
+}
+
+// Account is the user as shown to themselves and to admins, with the email.
+type Account struct {
+	User
+	Email               string     `json:"email"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+func (u User) Account() Account {
+	return Account{User: u, Email: u.Email, DeletionScheduledAt: u.DeletionScheduledAt}
+}
+
+// PublicUser is the profile shown to other users.
+type PublicUser struct {
+	ID          uint       `json:"id"`
+	Username    string     `json:"username"`
+	DisplayName *string    `json:"display_name"`
+	AvatarURL   *string    `json:"avatar_url"`
+	Bio         *string    `json:"bio"`
+	Signature   *string    `json:"signature"`
+	Location    *string    `json:"location"`
+	Links       []string   `json:"links"`
+	Role        string     `json:"role"`
+	Reputation  int        `json:"reputation"`
+	TrustLevel  int        `json:"trust_level"`
+	JoinedAt    time.Time  `json:"joined_at"`
+	Stats       *UserStats `json:"stats,omitempty"`
+}
+
+func (u User) Public() PublicUser {
+	links := u.Links
+	if links == nil {
+		links = []string{}
+	}
+
+	return PublicUser{
+		ID:          u.ID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+		AvatarURL:   u.AvatarURL,
+		Bio:         u.Bio,
+		Signature:   u.Signature,
+		Location:    u.Location,
+		Links:       links,
+		Role:        u.Role,
+		Reputation:  u.Reputation,
+		TrustLevel:  u.TrustLevel,
+		JoinedAt:    u.CreatedAt,
+	}
+}
+
+type UserStats struct {
+	PostCount         int64     `json:"post_count"`
+	ThreadsStarted    int64     `json:"threads_started"`
+	ReactionsReceived int64     `json:"reactions_received"`
+	Followers         int64     `json:"followers"`
+	Following         int64     `json:"following"`
+	JoinedAt          time.Time `json:"joined_at"`
 }
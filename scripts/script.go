@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"onichan/database"
 	"onichan/model"
+	"onichan/services"
 	"onichan/utils"
 	"os"
-
-	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"time"
 )
 
 func populateAvatar() {
@@ -94,25 +96,27 @@ func populateCategory() {
 
 func populateReaction() {
 	type Reaction struct {
-		Name  string
-		Emoji string
+		Name   string
+		Emoji  string
+		Weight int
 	}
 
 	reactions := []Reaction{
-		{Name: "like", Emoji: "👍"},
-		{Name: "dislike", Emoji: "👎"},
-		{Name: "love", Emoji: "❤️"},
-		{Name: "haha", Emoji: "😂"},
-		{Name: "wow", Emoji: "😮"},
+		{Name: "like", Emoji: "👍", Weight: 1},
+		{Name: "dislike", Emoji: "👎", Weight: -1},
+		{Name: "love", Emoji: "❤️", Weight: 2},
+		{Name: "haha", Emoji: "😂", Weight: 1},
+		{Name: "wow", Emoji: "😮", Weight: 1},
 		{Name: "sad", Emoji: "😢"},
 		{Name: "angry", Emoji: "😡"},
-		{Name: "congrats", Emoji: "🎉"},
+		{Name: "congrats", Emoji: "🎉", Weight: 1},
 	}
 
 	for _, reaction := range reactions {
 		r := model.Reaction{
-			Name:  reaction.Name,
-			Emoji: reaction.Emoji,
+			Name:   reaction.Name,
+			Emoji:  reaction.Emoji,
+			Weight: reaction.Weight,
 		}
 
 		if err := database.Database.Create(&r).Error; err != nil {
@@ -124,8 +128,7 @@ func populateReaction() {
 }
 
 func createAdmin(username string, email string, password string) {
-	salt := utils.GetToken(32)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(salt+password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(password)
 
 	if err != nil {
 		fmt.Println("Error hashing password")
@@ -133,14 +136,15 @@ func createAdmin(username string, email string, password string) {
 	}
 
 	randomAvatar := utils.GetRandomAvatar()
+	now := time.Now()
 
 	user := model.User{
-		Username:     username,
-		Email:        email,
-		PasswordHash: string(hashedPassword),
-		Salt:         salt,
-		AvatarURL:    &randomAvatar,
-		Role:         "admin",
+		Username:        username,
+		Email:           email,
+		PasswordHash:    hashedPassword,
+		AvatarURL:       &randomAvatar,
+		Role:            "admin",
+		EmailVerifiedAt: &now,
 	}
 
 	if err := database.Database.Create(&user).Error; err != nil {
@@ -151,7 +155,64 @@ func createAdmin(username string, email string, password string) {
 	fmt.Println("Admin created successfully")
 }
 
+func generateKey(alg string) {
+	kid, err := utils.GenerateSigningKey(utils.KeysDir(), alg)
+	if err != nil {
+		fmt.Println("Error generating key:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Generated %s key %s in %s\n", alg, kid, utils.KeysDir())
+}
+
+// rotateKey adds a new signing key and keeps only the key it replaces for
+// verification, so tokens signed before the rotation stay valid until they
+// expire. Rotate at most once per access token lifetime.
+func rotateKey(alg string) {
+	generateKey(alg)
+
+	removed, err := utils.PruneSigningKeys(utils.KeysDir(), 2)
+	if err != nil {
+		fmt.Println("Error removing old keys:", err)
+		os.Exit(1)
+	}
+
+	for _, kid := range removed {
+		fmt.Println("Removed key", kid)
+	}
+}
+
+// calibrateArgon2 measures Argon2id on this machine and prints the cost that
+// keeps a password hash within the target time.
+func calibrateArgon2(targetMs int, memoryKiB int, parallelism int) {
+	params, elapsed := utils.CalibrateArgon2(time.Duration(targetMs)*time.Millisecond, uint32(memoryKiB), uint8(parallelism))
+
+	fmt.Printf("Hashing took %v with these settings:\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("ARGON2_MEMORY=%d\n", params.Memory)
+	fmt.Printf("ARGON2_ITERATIONS=%d\n", params.Iterations)
+	fmt.Printf("ARGON2_PARALLELISM=%d\n", params.Parallelism)
+}
+
+// positiveArg reads the calibrate_argon2 argument at index, which has to be
+// an integer between 1 and max, falling back to def when it is missing.
+func positiveArg(index int, def int, max int) int {
+	if len(os.Args) <= index {
+		return def
+	}
+
+	value, err := strconv.Atoi(os.Args[index])
+	if err != nil || value < 1 || value > max {
+		fmt.Println("Usage: calibrate_argon2 [target_ms] [memory_kib] [parallelism]")
+		fmt.Println("Each value must be a positive integer; parallelism is at most 255")
+		os.Exit(1)
+	}
+	return value
+}
+
 func auto() {
+	if entries, _ := os.ReadDir(utils.KeysDir()); len(entries) == 0 {
+		generateKey("EdDSA")
+	}
 	populateAvatar()
 	populateReaction()
 	populateCategory()
@@ -165,6 +226,36 @@ func main() {
 	}
 
 	utils.LoadEnv()
+	utils.LoadPasswordHashing()
+
+	// Key commands only touch JWT_KEYS_DIR and do not need the database.
+	keyAlg := "EdDSA"
+	if len(os.Args) > 2 {
+		keyAlg = os.Args[2]
+	}
+
+	if os.Args[1] == "generate_key" {
+		generateKey(keyAlg)
+		os.Exit(0)
+	}
+
+	if os.Args[1] == "rotate_key" {
+		rotateKey(keyAlg)
+		os.Exit(0)
+	}
+
+	if os.Args[1] == "calibrate_argon2" {
+		targetMs := positiveArg(2, 500, math.MaxInt32)
+		memoryKiB := positiveArg(3, 64*1024, math.MaxInt32)
+		parallelism := positiveArg(4, 2, math.MaxUint8)
+		if memoryKiB < 8*parallelism {
+			fmt.Println("Memory must be at least 8 KiB per thread")
+			os.Exit(1)
+		}
+		calibrateArgon2(targetMs, memoryKiB, parallelism)
+		os.Exit(0)
+	}
+
 	database.Connect()
 
 	if os.Args[1] == "populate_avatar" {
@@ -191,6 +282,35 @@ func main() {
 		os.Exit(0)
 	}
 
+	if os.Args[1] == "cleanup_unverified" {
+		services.LoadVerificationEnv()
+		count, err := services.DeleteExpiredUnverifiedUsers()
+		if err != nil {
+			fmt.Println("Error deleting unverified users:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted %d unverified users\n", count)
+		os.Exit(0)
+	}
+
+	if os.Args[1] == "recalculate_reputation" {
+		if err := services.RecalculateReputation(); err != nil {
+			fmt.Println("Error recalculating reputation:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Reputation recalculated successfully")
+		os.Exit(0)
+	}
+
+	if os.Args[1] == "update_trust_levels" {
+		if err := services.UpdateAllTrustLevels(); err != nil {
+			fmt.Println("Error updating trust levels:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Trust levels updated successfully")
+		os.Exit(0)
+	}
+
 	if os.Args[1] == "auto" {
 		auto()
 		os.Exit(0)
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"onichan/websocket"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ACCOUNT_DELETION_GRACE_PERIOD is how many hours a requested account
+// deletion waits, so the user can change their mind.
+var ACCOUNT_DELETION_GRACE_PERIOD int
+
+var ErrDeletionNotScheduled = errors.New("no account deletion is scheduled")
+
+var uploadReference = regexp.MustCompile(`uploads/([^\s"'()<>\[\]]+)`)
+
+func LoadAccountDeletionEnv() {
+	var err error
+	ACCOUNT_DELETION_GRACE_PERIOD, err = strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"))
+	if err != nil || ACCOUNT_DELETION_GRACE_PERIOD < 0 {
+		ACCOUNT_DELETION_GRACE_PERIOD = 14 * 24
+	}
+}
+
+// ScheduleAccountDeletion deletes the account after the grace period unless
+// the user cancels it first.
+func ScheduleAccountDeletion(user model.User) (time.Time, error) {
+	if isLastAdmin(user) {
+		return time.Time{}, ErrLastAdmin
+	}
+
+	deleteAt := time.Now().Add(time.Duration(ACCOUNT_DELETION_GRACE_PERIOD) * time.Hour)
+	err := database.Database.Model(&user).Update("deletion_scheduled_at", deleteAt).Error
+	return deleteAt, err
+}
+
+func CancelAccountDeletion(user model.User) error {
+	if user.DeletionScheduledAt == nil {
+		return ErrDeletionNotScheduled
+	}
+	return database.Database.Model(&user).Update("deletion_scheduled_at", nil).Error
+}
+
+// DeleteAccount anonymizes the account right away. Its posts and messages
+// stay where they are under a placeholder name so threads keep their
+// structure, while the profile, credentials, reactions, notifications,
+// reports, follows and blocks are removed.
+func DeleteAccount(user model.User) error {
+	if isLastAdmin(user) {
+		return ErrLastAdmin
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		return anonymizeAccount(tx, user)
+	})
+	if err != nil {
+		return err
+	}
+
+	websocket.DisconnectUser(user.ID)
+	removeAvatarUploads(user.ID, "")
+	return nil
+}
+
+// anonymizeAccount does the database part of DeleteAccount within tx.
+func anonymizeAccount(tx *gorm.DB, user model.User) error {
+	if err := purgeCredentials(tx, []uint{user.ID}); err != nil {
+		return err
+	}
+
+	// Take back the reputation the user's reactions gave others
+	if err := tx.Exec(`UPDATE users SET reputation = users.reputation - given.total
+		FROM (
+			SELECT posts.user_id, SUM(reactions.weight) AS total
+			FROM post_reactions
+			JOIN posts ON posts.id = post_reactions.post_id
+			JOIN reactions ON reactions.id = post_reactions.reaction_id
+			WHERE post_reactions.user_id = ? AND posts.user_id <> ?
+				AND post_reactions.deleted_at IS NULL AND posts.deleted_at IS NULL
+			GROUP BY posts.user_id
+		) AS given
+		WHERE users.id = given.user_id`, user.ID, user.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.PostReaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ? OR from_user_id = ?", user.ID, user.ID).Delete(&model.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.Report{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("follower_id = ? OR following_id = ?", user.ID, user.ID).Delete(&model.Follow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ? OR target_id = ?", user.ID, user.ID).Delete(&model.Block{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&model.PostView{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserPreferences{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&model.EmailDebounce{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.RoleAssignment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Invite{}).Where("created_by_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&user).Updates(map[string]interface{}{
+		"username":              fmt.Sprintf("deleted_%d", user.ID),
+		"email":                 fmt.Sprintf("deleted_%d@deleted.invalid", user.ID),
+		"password_hash":         "",
+		"salt":                  "",
+		"avatar_url":            nil,
+		"bio":                   nil,
+		"display_name":          nil,
+		"signature":             nil,
+		"location":              nil,
+		"links":                 nil,
+		"reputation":            0,
+		"trust_level":           model.TrustLevelNew,
+		"trust_level_locked":    false,
+		"role":                  "user",
+		"email_verified_at":     nil,
+		"status":                model.UserStatusDeleted,
+		"invited_by_id":         nil,
+		"deletion_scheduled_at": nil,
+	}).Error
+}
+
+// DeleteScheduledAccounts deletes the accounts whose grace period is over.
+func DeleteScheduledAccounts() (int, error) {
+	var users []model.User
+	if err := database.Database.Where("deletion_scheduled_at <= ?", time.Now()).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, user := range users {
+		if err := DeleteAccount(user); err != nil {
+			log.Printf("Error deleting account %d: %v", user.ID, err)
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// StartAccountDeletions periodically runs DeleteScheduledAccounts. It blocks,
+// so run it in its own goroutine.
+func StartAccountDeletions(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := DeleteScheduledAccounts()
+		if err != nil {
+			log.Printf("Error deleting scheduled accounts: %v", err)
+		} else if count > 0 {
+			log.Printf("Deleted %d scheduled accounts", count)
+		}
+	}
+}
+
+// ExportUserData writes a zip archive with the user's profile, preferences,
+// posts, reactions, notifications, reports and sent messages as JSON,
+// together with the uploaded images their posts and profile refer to.
+func ExportUserData(user model.User, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	stats, err := GetUserStats(user)
+	if err != nil {
+		return err
+	}
+	profile := struct {
+		Account model.Account   `json:"account"`
+		Stats   model.UserStats `json:"stats"`
+	}{user.Account(), stats}
+	if err := writeExportJSON(archive, "profile.json", profile); err != nil {
+		return err
+	}
+
+	preferences, err := GetPreferences(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "preferences.json", preferences); err != nil {
+		return err
+	}
+
+	var posts []model.Post
+	if err := database.Database.Unscoped().Where("user_id = ?", user.ID).Order("created_at").Find(&posts).Error; err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "posts.json", posts); err != nil {
+		return err
+	}
+
+	var reactions []model.PostReaction
+	if err := database.Database.Preload("Reaction").Where("user_id = ?", user.ID).Order("created_at").Find(&reactions).Error; err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "reactions.json", reactions); err != nil {
+		return err
+	}
+
+	var notifications []model.Notification
+	if err := database.Database.Where("user_id = ?", user.ID).Order("created_at").Find(&notifications).Error; err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "notifications.json", notifications); err != nil {
+		return err
+	}
+
+	var reports []model.Report
+	if err := database.Database.Where("user_id = ?", user.ID).Order("created_at").Find(&reports).Error; err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "reports.json", reports); err != nil {
+		return err
+	}
+
+	var messages []model.Message
+	if err := database.Database.Where("user_id = ?", user.ID).Order("created_at").Find(&messages).Error; err != nil {
+		return err
+	}
+	if err := writeExportJSON(archive, "messages.json", messages); err != nil {
+		return err
+	}
+
+	texts := []string{}
+	if user.AvatarURL != nil {
+		texts = append(texts, *user.AvatarURL)
+	}
+	for _, post := range posts {
+		texts = append(texts, post.Content)
+	}
+	if err := writeExportUploads(archive, texts); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeExportJSON(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeExportUploads adds the uploaded files referenced in the texts. Files
+// that no longer exist are skipped.
+func writeExportUploads(archive *zip.Writer, texts []string) error {
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, match := range uploadReference.FindAllStringSubmatch(text, -1) {
+			name, err := url.PathUnescape(match[1])
+			if err != nil {
+				continue
+			}
+			name = filepath.Base(name)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			if err := copyExportFile(archive, filepath.Join(utils.UploadPath(), name), "uploads/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func copyExportFile(archive *zip.Writer, path, name string) error {
+	source, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, source)
+	return err
+}
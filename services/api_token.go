@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"time"
+)
+
+var ErrInvalidScope = errors.New("invalid scope")
+var ErrScopeNotAllowed = errors.New("admin scopes need the matching permission")
+
+// scopePermissions maps admin scopes to the permission a user needs to grant
+// them to a token.
+var scopePermissions = map[string]string{
+	model.ScopeAdminReports:    model.PermissionResolveReports,
+	model.ScopeAdminCategories: model.PermissionManageCategories,
+	model.ScopeAdminReactions:  model.PermissionManageReactions,
+}
+
+// CreateAPIToken creates a personal access token for the user and returns it
+// together with the raw token, which is not stored and cannot be shown again.
+func CreateAPIToken(userID uint, role, name string, scopes []string, ttl time.Duration) (model.APIToken, string, error) {
+	for _, scope := range scopes {
+		if !isValidScope(scope) {
+			return model.APIToken{}, "", ErrInvalidScope
+		}
+		if permission, ok := scopePermissions[scope]; ok && !HasPermissionAnywhere(userID, role, permission) {
+			return model.APIToken{}, "", ErrScopeNotAllowed
+		}
+	}
+
+	rawToken := model.APITokenPrefix + utils.GenerateSecureToken(32)
+	token := model.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawToken[:len(model.APITokenPrefix)+6],
+		TokenHash: utils.HashToken(rawToken),
+		Scopes:    scopes,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := database.Database.Create(&token).Error; err != nil {
+		return token, "", err
+	}
+
+	return token, rawToken, nil
+}
+
+func ListAPITokens(userID uint) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	err := database.Database.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeAPIToken revokes one of the user's tokens. It returns
+// gorm.ErrRecordNotFound when the token does not exist or belongs to someone
+// else.
+func RevokeAPIToken(userID, tokenID uint) error {
+	var token model.APIToken
+	if err := database.Database.Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).First(&token).Error; err != nil {
+		return err
+	}
+
+	return database.Database.Model(&token).Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserAPITokens revokes every API token of the user, for when their
+// account may have been compromised.
+func RevokeUserAPITokens(userID uint) error {
+	return database.Database.Model(&model.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func isValidScope(scope string) bool {
+	for _, s := range model.APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+)
+
+// RecordAudit adds an entry to the audit log. Failures are logged rather
+// than returned, since the action itself already happened.
+func RecordAudit(actorID uint, action string, targetUserID uint, details map[string]interface{}) {
+	entry := model.AuditLog{
+		ActorID:      &actorID,
+		Action:       action,
+		TargetUserID: &targetUserID,
+		Details:      details,
+	}
+
+	var actor model.User
+	if err := database.Database.Select("username").First(&actor, actorID).Error; err == nil {
+		entry.ActorUsername = actor.Username
+	}
+
+	if err := database.Database.Create(&entry).Error; err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/url"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AvatarSizes are the edge lengths in pixels an uploaded avatar is stored in,
+// largest first. The user's avatar_url points at the largest one.
+var AvatarSizes = []int{256, 128, 64}
+
+// MaxAvatarDimension caps the width and height of uploaded avatars so huge
+// images are rejected before they are decoded.
+const MaxAvatarDimension = 4096
+
+var (
+	ErrAvatarNotAllowed   = errors.New("avatar must be one of the gallery avatars or your own upload")
+	ErrInvalidAvatarImage = errors.New("avatar must be a jpeg, png or gif image")
+	ErrAvatarTooLarge     = errors.New("avatar must be at most 4096 by 4096 pixels")
+	ErrInvalidAvatarURL   = errors.New("avatar URL must be an http or https URL or an uploads/ path of at most 255 characters")
+	ErrLastAvatar         = errors.New("the last gallery avatar cannot be removed")
+)
+
+// avatarUpload matches the URL of an uploaded avatar and captures the user it
+// belongs to.
+var avatarUpload = regexp.MustCompile(`^uploads/avatars/(\d+)_\d+_\d+\.png$`)
+
+func avatarDirectory() string {
+	return filepath.Join(utils.UploadPath(), "avatars")
+}
+
+// ValidateAvatarURL checks a gallery avatar URL. Uploaded user avatars cannot
+// become gallery avatars, since they are removed when their owner uploads a
+// new one.
+func ValidateAvatarURL(avatarURL string) error {
+	if len(avatarURL) > 255 || strings.HasPrefix(avatarURL, "uploads/avatars/") {
+		return ErrInvalidAvatarURL
+	}
+	if strings.HasPrefix(avatarURL, "uploads/") && !strings.Contains(avatarURL, "..") {
+		return nil
+	}
+
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidAvatarURL
+	}
+	return nil
+}
+
+// CreateAvatar adds an avatar to the gallery.
+func CreateAvatar(avatarURL string) (model.Avatar, error) {
+	avatar := model.Avatar{AvatarURL: avatarURL}
+	if err := ValidateAvatarURL(avatarURL); err != nil {
+		return avatar, err
+	}
+
+	err := database.Database.Create(&avatar).Error
+	return avatar, err
+}
+
+// UpdateAvatar changes the URL of a gallery avatar. Users wearing it move to
+// the new URL.
+func UpdateAvatar(avatar model.Avatar, avatarURL string) (model.Avatar, error) {
+	if err := ValidateAvatarURL(avatarURL); err != nil {
+		return avatar, err
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("avatar_url = ?", avatar.AvatarURL).Update("avatar_url", avatarURL).Error; err != nil {
+			return err
+		}
+		return tx.Model(&avatar).Update("avatar_url", avatarURL).Error
+	})
+	return avatar, err
+}
+
+// DeleteAvatar removes an avatar from the gallery. Users wearing it move to a
+// random one of the remaining avatars, so the gallery can never run empty.
+func DeleteAvatar(avatar model.Avatar) error {
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		// Lock the replacement so a concurrent delete cannot remove it too
+		var replacement model.Avatar
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id <> ?", avatar.ID).
+			Order("RANDOM()").
+			First(&replacement).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLastAvatar
+		} else if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.User{}).Where("avatar_url = ?", avatar.AvatarURL).Update("avatar_url", replacement.AvatarURL).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&avatar).Error
+	})
+}
+
+// CanUseAvatar reports whether the user may wear avatarURL: a gallery avatar
+// or one of the sizes of their own uploaded avatar.
+func CanUseAvatar(userID uint, avatarURL string) bool {
+	if match := avatarUpload.FindStringSubmatch(avatarURL); match != nil {
+		if match[1] != strconv.FormatUint(uint64(userID), 10) {
+			return false
+		}
+		_, err := os.Stat(filepath.Join(utils.UploadPath(), strings.TrimPrefix(avatarURL, "uploads/")))
+		return err == nil
+	}
+
+	var count int64
+	database.Database.Model(&model.Avatar{}).Where("avatar_url = ?", avatarURL).Count(&count)
+	return count > 0
+}
+
+// SetAvatar changes the user's avatar after checking they may use it.
+func SetAvatar(user model.User, avatarURL string) error {
+	if !CanUseAvatar(user.ID, avatarURL) {
+		return ErrAvatarNotAllowed
+	}
+
+	return database.Database.Model(&user).Update("avatar_url", avatarURL).Error
+}
+
+// UploadAvatar crops the image to a square, stores it in every AvatarSizes
+// size as png and makes the largest one the user's avatar. The user's
+// previously uploaded avatar is removed. It returns the URL of each size.
+func UploadAvatar(user model.User, file io.ReadSeeker) (map[int]string, error) {
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, ErrInvalidAvatarImage
+	}
+	if config.Width > MaxAvatarDimension || config.Height > MaxAvatarDimension {
+		return nil, ErrAvatarTooLarge
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, ErrInvalidAvatarImage
+	}
+
+	if err := os.MkdirAll(avatarDirectory(), 0755); err != nil {
+		return nil, err
+	}
+
+	square := utils.CropSquare(img)
+	prefix := fmt.Sprintf("%d_%d_", user.ID, time.Now().UnixNano())
+	urls := make(map[int]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		name := prefix + strconv.Itoa(size) + ".png"
+		urls[size] = "uploads/avatars/" + name
+		if err := writePNG(filepath.Join(avatarDirectory(), name), utils.Resize(square, size, size)); err != nil {
+			removeFiles(urls)
+			return nil, err
+		}
+	}
+
+	if err := database.Database.Model(&user).Update("avatar_url", urls[AvatarSizes[0]]).Error; err != nil {
+		removeFiles(urls)
+		return nil, err
+	}
+
+	removeAvatarUploads(user.ID, prefix)
+	return urls, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(out, img); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// removeFiles deletes the uploads/ files behind urls.
+func removeFiles(urls map[int]string) {
+	for _, avatarURL := range urls {
+		if err := os.Remove(filepath.Join(utils.UploadPath(), strings.TrimPrefix(avatarURL, "uploads/"))); err != nil && !os.IsNotExist(err) {
+			fmt.Println(err)
+		}
+	}
+}
+
+// removeAvatarUploads deletes the user's uploaded avatar files, except the
+// ones whose name starts with keep.
+func removeAvatarUploads(userID uint, keep string) {
+	files, err := filepath.Glob(filepath.Join(avatarDirectory(), fmt.Sprintf("%d_*.png", userID)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, file := range files {
+		if keep != "" && strings.HasPrefix(filepath.Base(file), keep) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/websocket"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidBanScope = errors.New("scope must be full, read_only or category")
+var ErrBanCategoryRequired = errors.New("category bans need a category_id")
+var ErrCannotBanUser = errors.New("this user cannot be banned")
+
+// activeBans selects bans that have neither expired nor been lifted. Expired
+// bans need no cleanup; they simply stop matching.
+func activeBans() *gorm.DB {
+	return database.Database.Model(&model.Ban{}).
+		Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+// GetAccountBan returns the user's active full or read-only ban, preferring a
+// full ban and then the one that lasts longest.
+func GetAccountBan(userID uint) (model.Ban, error) {
+	var ban model.Ban
+	err := activeBans().
+		Where("user_id = ? AND scope IN ?", userID, []string{model.BanScopeFull, model.BanScopeReadOnly}).
+		Order("scope = 'full' DESC, expires_at DESC NULLS FIRST").
+		First(&ban).Error
+	return ban, err
+}
+
+// GetCategoryBan returns the user's active ban that keeps them from writing
+// in the category, including a read-only ban.
+func GetCategoryBan(userID, categoryID uint) (model.Ban, error) {
+	var ban model.Ban
+	err := activeBans().
+		Where("user_id = ? AND (scope IN ? OR (scope = ? AND category_id = ?))",
+			userID, []string{model.BanScopeFull, model.BanScopeReadOnly}, model.BanScopeCategory, categoryID).
+		Order("expires_at DESC NULLS FIRST").
+		First(&ban).Error
+	return ban, err
+}
+
+// IssueBan bans a user. Nobody can ban themselves or a user holding
+// permissions they lack. A full ban also ends the user's sessions and drops
+// their websocket connection.
+func IssueBan(ban model.Ban, issuedByRole string) (model.Ban, error) {
+	switch ban.Scope {
+	case model.BanScopeFull, model.BanScopeReadOnly:
+		ban.CategoryID = nil
+	case model.BanScopeCategory:
+		if ban.CategoryID == nil {
+			return ban, ErrBanCategoryRequired
+		}
+	default:
+		return ban, ErrInvalidBanScope
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, ban.UserID).Error; err != nil {
+		return ban, err
+	}
+	if user.ID == ban.IssuedByID {
+		return ban, ErrCannotBanUser
+	}
+	if err := CanManageUser(ban.IssuedByID, issuedByRole, user); errors.Is(err, ErrTargetMorePrivileged) || errors.Is(err, ErrLastAdmin) {
+		return ban, ErrCannotBanUser
+	} else if err != nil {
+		return ban, err
+	}
+
+	if err := database.Database.Create(&ban).Error; err != nil {
+		return ban, err
+	}
+
+	if ban.Scope == model.BanScopeFull {
+		if err := RevokeUserSessions(ban.UserID, 0); err != nil {
+			return ban, err
+		}
+		websocket.DisconnectUser(ban.UserID)
+	}
+
+	return ban, nil
+}
+
+func LiftBan(ban model.Ban, liftedByID uint) (model.Ban, error) {
+	now := time.Now()
+	ban.LiftedAt = &now
+	ban.LiftedByID = &liftedByID
+
+	err := database.Database.Model(&ban).Updates(map[string]interface{}{
+		"lifted_at":    ban.LiftedAt,
+		"lifted_by_id": ban.LiftedByID,
+	}).Error
+	return ban, err
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrBlockSelf = errors.New("you cannot block or mute yourself")
+
+// SetBlock mutes or blocks the target, replacing a previous mute or block.
+// Blocking also removes the target's follow of the user.
+func SetBlock(userID, targetID uint, level string) (model.Block, error) {
+	if userID == targetID {
+		return model.Block{}, ErrBlockSelf
+	}
+
+	if err := database.Database.First(&model.User{}, targetID).Error; err != nil {
+		return model.Block{}, err
+	}
+
+	block := model.Block{UserID: userID, TargetID: targetID, Level: level}
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"level", "updated_at"}),
+		}).Create(&block).Error; err != nil {
+			return err
+		}
+
+		if level == model.BlockLevelBlock {
+			return tx.Unscoped().Where("follower_id = ? AND following_id = ?", targetID, userID).Delete(&model.Follow{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return block, err
+	}
+
+	err = database.Database.Preload("Target").First(&block, "user_id = ? AND target_id = ?", userID, targetID).Error
+	return block, err
+}
+
+// RemoveBlock lifts a mute or block of the given level.
+func RemoveBlock(userID, targetID uint, level string) error {
+	result := database.Database.Unscoped().
+		Where("user_id = ? AND target_id = ? AND level = ?", userID, targetID, level).
+		Delete(&model.Block{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IsBlocked reports whether ownerID blocked actorID.
+func IsBlocked(ownerID, actorID uint) bool {
+	var count int64
+	database.Database.Model(&model.Block{}).
+		Where("user_id = ? AND target_id = ? AND level = ?", ownerID, actorID, model.BlockLevelBlock).
+		Count(&count)
+	return count > 0
+}
+
+// IsSilenced reports whether the recipient muted or blocked the sender, so
+// the sender's activity should not reach them.
+func IsSilenced(recipientID, senderID uint) bool {
+	var count int64
+	database.Database.Model(&model.Block{}).
+		Where("user_id = ? AND target_id = ?", recipientID, senderID).
+		Count(&count)
+	return count > 0
+}
+
+// SilencedBy returns the users who muted or blocked the sender.
+func SilencedBy(senderID uint) (map[uint]bool, error) {
+	var userIDs []uint
+	if err := database.Database.Model(&model.Block{}).
+		Where("target_id = ?", senderID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+
+	silenced := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		silenced[userID] = true
+	}
+	return silenced, nil
+}
+
+// HiddenAuthors returns the users the viewer muted or blocked, whose posts are
+// collapsed for them.
+func HiddenAuthors(viewerID uint) (map[uint]bool, error) {
+	var targetIDs []uint
+	if err := database.Database.Model(&model.Block{}).
+		Where("user_id = ?", viewerID).
+		Pluck("target_id", &targetIDs).Error; err != nil {
+		return nil, err
+	}
+
+	hidden := make(map[uint]bool, len(targetIDs))
+	for _, targetID := range targetIDs {
+		hidden[targetID] = true
+	}
+	return hidden, nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/bits"
+	"onichan/database"
+	"onichan/model"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ChallengeActionRegister = "register"
+	ChallengeActionPost     = "post"
+)
+
+// Solved challenges are sent in these request headers.
+const (
+	ChallengeHeader         = "X-Challenge"
+	ChallengeSolutionHeader = "X-Challenge-Solution"
+)
+
+// challengeSettings maps each action to the setting that turns the challenge
+// on for every request. Categories can require it for posts on their own.
+var challengeSettings = map[string]string{
+	ChallengeActionRegister: SettingChallengeRegister,
+	ChallengeActionPost:     SettingChallengePosts,
+}
+
+var CHALLENGE_SECRET []byte
+var CHALLENGE_DIFFICULTY int
+var CHALLENGE_MAX_DIFFICULTY int
+var CHALLENGE_LOAD_THRESHOLD int
+var CHALLENGE_TTL int
+
+var ErrUnknownChallengeAction = errors.New("unknown challenge action")
+var ErrChallengeRequired = errors.New("a solved challenge is required for this action")
+var ErrInvalidChallenge = errors.New("invalid or expired challenge")
+var ErrChallengeUnsolved = errors.New("the challenge solution is incorrect")
+var ErrChallengeReused = errors.New("this challenge has already been used")
+
+func LoadChallengeEnv() {
+	CHALLENGE_SECRET = []byte(os.Getenv("CHALLENGE_SECRET"))
+	if len(CHALLENGE_SECRET) == 0 {
+		// Challenges then only verify on this instance and until it restarts.
+		CHALLENGE_SECRET = make([]byte, 32)
+		rand.Read(CHALLENGE_SECRET)
+	}
+
+	CHALLENGE_DIFFICULTY, _ = strconv.Atoi(os.Getenv("CHALLENGE_DIFFICULTY"))
+	if CHALLENGE_DIFFICULTY <= 0 {
+		CHALLENGE_DIFFICULTY = 18
+	}
+	CHALLENGE_MAX_DIFFICULTY, _ = strconv.Atoi(os.Getenv("CHALLENGE_MAX_DIFFICULTY"))
+	if CHALLENGE_MAX_DIFFICULTY < CHALLENGE_DIFFICULTY {
+		CHALLENGE_MAX_DIFFICULTY = CHALLENGE_DIFFICULTY + 6
+	}
+	CHALLENGE_LOAD_THRESHOLD, _ = strconv.Atoi(os.Getenv("CHALLENGE_LOAD_THRESHOLD"))
+	if CHALLENGE_LOAD_THRESHOLD <= 0 {
+		CHALLENGE_LOAD_THRESHOLD = 60
+	}
+	CHALLENGE_TTL, _ = strconv.Atoi(os.Getenv("CHALLENGE_TTL"))
+	if CHALLENGE_TTL <= 0 {
+		CHALLENGE_TTL = 300
+	}
+}
+
+// Challenge is a proof-of-work puzzle: find a solution such that
+// SHA-256(challenge + solution) starts with Difficulty zero bits.
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Action     string    `json:"action"`
+	Difficulty int       `json:"difficulty"`
+	Algorithm  string    `json:"algorithm"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// challengePayload is signed into the challenge string, so verifying a
+// solution needs no stored state.
+type challengePayload struct {
+	Action     string `json:"a"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"e"`
+	Nonce      string `json:"n"`
+}
+
+// challengeLoad counts the challenges issued in the current and previous
+// minute to raise the difficulty while many are requested.
+var challengeLoad struct {
+	mu       sync.Mutex
+	minute   int64
+	current  int
+	previous int
+}
+
+// usedChallenges remembers solved challenges until they expire so a solution
+// cannot be replayed on this instance.
+var usedChallenges = struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}{entries: make(map[string]time.Time)}
+
+// ChallengeRequired reports whether every request for the action must carry a
+// solved challenge.
+func ChallengeRequired(action string) bool {
+	setting, ok := challengeSettings[action]
+	return ok && GetBoolSetting(setting)
+}
+
+// ExemptFromChallenge reports whether the user is trusted enough to skip
+// challenges: staff, and verified members whose account is at least
+// challenge_trusted_account_days old.
+func ExemptFromChallenge(userID uint, role string) bool {
+	if IsStaff(userID, role) {
+		return true
+	}
+
+	minDays := GetIntSetting(SettingChallengeTrustedAccountDays)
+	if minDays < 0 {
+		return false
+	}
+
+	var user model.User
+	if err := database.Database.Select("created_at", "email_verified_at").First(&user, userID).Error; err != nil {
+		return false
+	}
+
+	return user.EmailVerifiedAt != nil && time.Since(user.CreatedAt) >= time.Duration(minDays)*24*time.Hour
+}
+
+// CheckChallenge lets exempt users through and otherwise verifies the
+// solution. userID is nil for anonymous requests.
+func CheckChallenge(action string, userID *uint, role, challenge, solution string) error {
+	if userID != nil && ExemptFromChallenge(*userID, role) {
+		return nil
+	}
+
+	return VerifyChallenge(action, challenge, solution)
+}
+
+func IssueChallenge(action string) (Challenge, error) {
+	if _, ok := challengeSettings[action]; !ok {
+		return Challenge{}, ErrUnknownChallengeAction
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return Challenge{}, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(CHALLENGE_TTL) * time.Second)
+	payload := challengePayload{
+		Action:     action,
+		Difficulty: currentDifficulty(),
+		ExpiresAt:  expiresAt.Unix(),
+		Nonce:      base64.RawURLEncoding.EncodeToString(nonce),
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return Challenge{}, err
+	}
+	body := base64.RawURLEncoding.EncodeToString(encoded)
+
+	return Challenge{
+		Challenge:  body + "." + signChallenge(body),
+		Action:     action,
+		Difficulty: payload.Difficulty,
+		Algorithm:  "sha256",
+		ExpiresAt:  time.Unix(payload.ExpiresAt, 0),
+	}, nil
+}
+
+// VerifyChallenge checks that challenge was issued by this server for the
+// action, has not expired or been used, and that solution solves it.
+func VerifyChallenge(action, challenge, solution string) error {
+	if challenge == "" || solution == "" {
+		return ErrChallengeRequired
+	}
+
+	body, signature, ok := strings.Cut(challenge, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signChallenge(body))) {
+		return ErrInvalidChallenge
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+	var payload challengePayload
+	if err := json.Unmarshal(decoded, &payload); err != nil {
+		return ErrInvalidChallenge
+	}
+
+	expiresAt := time.Unix(payload.ExpiresAt, 0)
+	if payload.Action != action || time.Now().After(expiresAt) {
+		return ErrInvalidChallenge
+	}
+
+	sum := sha256.Sum256([]byte(challenge + solution))
+	if leadingZeroBits(sum[:]) < payload.Difficulty {
+		return ErrChallengeUnsolved
+	}
+
+	usedChallenges.mu.Lock()
+	defer usedChallenges.mu.Unlock()
+
+	now := time.Now()
+	for nonce, expiry := range usedChallenges.entries {
+		if now.After(expiry) {
+			delete(usedChallenges.entries, nonce)
+		}
+	}
+	if _, used := usedChallenges.entries[payload.Nonce]; used {
+		return ErrChallengeReused
+	}
+	usedChallenges.entries[payload.Nonce] = expiresAt
+
+	return nil
+}
+
+func signChallenge(body string) string {
+	mac := hmac.New(sha256.New, CHALLENGE_SECRET)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// currentDifficulty adds a bit of work, doubling the expected effort, each
+// time the rate of issued challenges doubles past CHALLENGE_LOAD_THRESHOLD per
+// minute.
+func currentDifficulty() int {
+	challengeLoad.mu.Lock()
+	minute := time.Now().Unix() / 60
+	switch minute - challengeLoad.minute {
+	case 0:
+	case 1:
+		challengeLoad.previous = challengeLoad.current
+		challengeLoad.current = 0
+	default:
+		challengeLoad.previous = 0
+		challengeLoad.current = 0
+	}
+	challengeLoad.minute = minute
+	challengeLoad.current++
+	rate := math.Max(float64(challengeLoad.current), float64(challengeLoad.previous))
+	challengeLoad.mu.Unlock()
+
+	difficulty := CHALLENGE_DIFFICULTY
+	if rate > float64(CHALLENGE_LOAD_THRESHOLD) {
+		difficulty += int(math.Ceil(math.Log2(rate / float64(CHALLENGE_LOAD_THRESHOLD))))
+	}
+
+	if difficulty > CHALLENGE_MAX_DIFFICULTY {
+		return CHALLENGE_MAX_DIFFICULTY
+	}
+	return difficulty
+}
+
+func leadingZeroBits(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"onichan/websocket"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// MaxConversationParticipants caps the size of group conversations,
+// including the user who starts them.
+const MaxConversationParticipants = 10
+
+// MaxMessageLength caps the length of a message in characters.
+const MaxMessageLength = 5000
+
+// MaxMessagesPerMinute caps how many messages a user can send within a
+// minute, across all of their conversations.
+const MaxMessagesPerMinute = 20
+
+var (
+	ErrNoRecipients          = errors.New("a conversation needs at least one other user")
+	ErrTooManyParticipants   = errors.New("too many users in this conversation")
+	ErrRecipientNotFound     = errors.New("user not found")
+	ErrMessagingBlocked      = errors.New("you cannot message this user")
+	ErrNotParticipant        = errors.New("you are not part of this conversation")
+	ErrEmptyMessage          = errors.New("message cannot be empty")
+	ErrConversationTitleSize = errors.New("title must be at most 127 characters")
+	ErrMessageTooLong        = errors.New("message must be at most 5000 characters")
+	ErrMessageRateLimited    = errors.New("you are sending messages too quickly, please wait a moment")
+)
+
+// checkMessage rejects empty and overly long messages, and senders who went
+// over MaxMessagesPerMinute.
+func checkMessage(userID uint, content string) error {
+	if content == "" {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(content) > MaxMessageLength {
+		return ErrMessageTooLong
+	}
+
+	var recent int64
+	if err := database.Database.Model(&model.Message{}).
+		Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-time.Minute)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent >= MaxMessagesPerMinute {
+		return ErrMessageRateLimited
+	}
+	return nil
+}
+
+// canMessage reports whether none of the recipients blocked the sender.
+func canMessage(senderID uint, recipientIDs []uint) bool {
+	var count int64
+	database.Database.Model(&model.Block{}).
+		Where("user_id IN ? AND target_id = ? AND level = ?", recipientIDs, senderID, model.BlockLevelBlock).
+		Count(&count)
+	return count == 0
+}
+
+func otherParticipants(conversationID, userID uint) ([]uint, error) {
+	var userIDs []uint
+	err := database.Database.Model(&model.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id <> ?", conversationID, userID).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// GetConversation returns the conversation with its participants when the
+// user is part of it.
+func GetConversation(conversationID, userID uint) (model.Conversation, error) {
+	var conversation model.Conversation
+	err := database.Database.
+		Preload("Participants").
+		Preload("Participants.User").
+		Where("id IN (?)", database.Database.Model(&model.ConversationParticipant{}).Select("conversation_id").Where("user_id = ?", userID)).
+		First(&conversation, conversationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return conversation, ErrNotParticipant
+	}
+	return conversation, err
+}
+
+// findDirectConversation returns the existing one-to-one conversation between
+// the two users without a title, if any.
+func findDirectConversation(userID, otherID uint) (model.Conversation, error) {
+	var conversation model.Conversation
+	err := database.Database.
+		Where("title IS NULL").
+		Where("id IN (?)", database.Database.Model(&model.ConversationParticipant{}).
+			Select("conversation_id").
+			Group("conversation_id").
+			Having("COUNT(*) = 2 AND COUNT(*) FILTER (WHERE user_id IN ?) = 2", []uint{userID, otherID})).
+		First(&conversation).Error
+	return conversation, err
+}
+
+// StartConversation sends the first message to the recipients. A one-to-one
+// conversation without a title continues the existing one between the two
+// users.
+func StartConversation(userID uint, recipientIDs []uint, title *string, content string) (model.Conversation, model.Message, error) {
+	recipients := []uint{}
+	seen := map[uint]bool{userID: true}
+	for _, id := range recipientIDs {
+		if !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+
+	if len(recipients) == 0 {
+		return model.Conversation{}, model.Message{}, ErrNoRecipients
+	}
+	if len(recipients)+1 > MaxConversationParticipants {
+		return model.Conversation{}, model.Message{}, ErrTooManyParticipants
+	}
+	if title != nil && len(*title) > 127 {
+		return model.Conversation{}, model.Message{}, ErrConversationTitleSize
+	}
+
+	var count int64
+	if err := database.Database.Model(&model.User{}).Where("id IN ? AND status = ?", recipients, model.UserStatusActive).Count(&count).Error; err != nil {
+		return model.Conversation{}, model.Message{}, err
+	}
+	if int(count) != len(recipients) {
+		return model.Conversation{}, model.Message{}, ErrRecipientNotFound
+	}
+
+	if !canMessage(userID, recipients) {
+		return model.Conversation{}, model.Message{}, ErrMessagingBlocked
+	}
+
+	if len(recipients) == 1 && title == nil {
+		conversation, err := findDirectConversation(userID, recipients[0])
+		if err == nil {
+			message, err := SendMessage(conversation.ID, userID, content)
+			return conversation, message, err
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return conversation, model.Message{}, err
+		}
+	}
+
+	if err := checkMessage(userID, content); err != nil {
+		return model.Conversation{}, model.Message{}, err
+	}
+
+	now := time.Now()
+	conversation := model.Conversation{
+		Title:         title,
+		CreatedByID:   userID,
+		LastMessageAt: now,
+		Participants:  []model.ConversationParticipant{{UserID: userID, LastReadAt: &now}},
+	}
+	for _, id := range recipients {
+		conversation.Participants = append(conversation.Participants, model.ConversationParticipant{UserID: id})
+	}
+	message := model.Message{UserID: userID, Content: content}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&conversation).Error; err != nil {
+			return err
+		}
+		message.ConversationID = conversation.ID
+		return tx.Create(&message).Error
+	})
+	if err != nil {
+		return conversation, message, err
+	}
+
+	deliverMessage(message, recipients)
+	return conversation, message, nil
+}
+
+// SendMessage adds a message to a conversation the user is part of. Nobody
+// can message a conversation where another participant blocked them.
+func SendMessage(conversationID, userID uint, content string) (model.Message, error) {
+	if err := checkMessage(userID, content); err != nil {
+		return model.Message{}, err
+	}
+
+	if _, err := GetConversation(conversationID, userID); err != nil {
+		return model.Message{}, err
+	}
+
+	recipients, err := otherParticipants(conversationID, userID)
+	if err != nil {
+		return model.Message{}, err
+	}
+	if len(recipients) > 0 && !canMessage(userID, recipients) {
+		return model.Message{}, ErrMessagingBlocked
+	}
+
+	message := model.Message{ConversationID: conversationID, UserID: userID, Content: content}
+	err = database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Conversation{}).Where("id = ?", conversationID).Update("last_message_at", message.CreatedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+			Update("last_read_at", message.CreatedAt).Error
+	})
+	if err != nil {
+		return message, err
+	}
+
+	deliverMessage(message, recipients)
+	return message, nil
+}
+
+// deliverMessage pushes the message over the websocket to the recipients who
+// have not muted or blocked its author.
+func deliverMessage(message model.Message, recipients []uint) {
+	database.Database.Preload("User").First(&message, message.ID)
+
+	for _, recipientID := range recipients {
+		if IsSilenced(recipientID, message.UserID) {
+			continue
+		}
+
+		websocket.SendDirectMessage(recipientID, message)
+		if preferences, err := GetPreferences(recipientID); err == nil && preferences.EmailOnMessage {
+			go emailMessage(recipientID, message)
+		}
+	}
+}
+
+// emailMessage tells a user who asked for emails about a new message, at
+// most once per conversation every NOTIFICATION_EMAIL_INTERVAL minutes until
+// they read it.
+func emailMessage(recipientID uint, message model.Message) {
+	if !claimEmail(recipientID, conversationEmailKey(message.ConversationID)) {
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, recipientID).Error; err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	subject := "New message from " + message.User.Username
+	link := os.Getenv("FRONTEND_URL") + "/conversations/" + strconv.Itoa(int(message.ConversationID))
+	if err := SendEmail(user.Email, subject, subject+": "+link); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func conversationEmailKey(conversationID uint) string {
+	return "conversation:" + strconv.Itoa(int(conversationID))
+}
+
+// ListConversations returns the user's conversations with the most recent
+// activity first, each with the number of unread messages.
+func ListConversations(userID uint, page, limit int) ([]model.Conversation, int64, error) {
+	query := database.Database.Model(&model.Conversation{}).
+		Where("id IN (?)", database.Database.Model(&model.ConversationParticipant{}).Select("conversation_id").Where("user_id = ?", userID))
+
+	var conversations []model.Conversation
+	if err := query.Session(&gorm.Session{}).
+		Preload("Participants").
+		Preload("Participants.User").
+		Order("last_message_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&conversations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	var unread []struct {
+		ConversationID uint
+		Count          int64
+	}
+	if err := unreadMessages(userID).
+		Where("messages.conversation_id IN ?", ids).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Group("messages.conversation_id").
+		Scan(&unread).Error; err != nil {
+		return nil, 0, err
+	}
+
+	counts := make(map[uint]int64)
+	for _, row := range unread {
+		counts[row.ConversationID] = row.Count
+	}
+	for i := range conversations {
+		conversations[i].UnreadCount = counts[conversations[i].ID]
+	}
+
+	return conversations, count, nil
+}
+
+// unreadMessages selects the messages other users sent the user after they
+// last read their conversations.
+func unreadMessages(userID uint) *gorm.DB {
+	return database.Database.Model(&model.Message{}).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id AND conversation_participants.user_id = ?", userID).
+		Where("messages.user_id <> ?", userID).
+		Where("conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at")
+}
+
+// CountUnreadMessages returns the number of unread messages across all of
+// the user's conversations.
+func CountUnreadMessages(userID uint) (int64, error) {
+	var count int64
+	err := unreadMessages(userID).Count(&count).Error
+	return count, err
+}
+
+// GetMessages returns a conversation's messages, newest first, with the
+// cursor for the next page.
+func GetMessages(conversationID, userID uint, cursor string, limit int) ([]model.Message, string, error) {
+	if _, err := GetConversation(conversationID, userID); err != nil {
+		return nil, "", err
+	}
+
+	query := database.Database.Where("conversation_id = ?", conversationID)
+	if cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var messages []model.Message
+	if err := query.
+		Preload("User").
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1).
+		Find(&messages).Error; err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		next = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return messages, next, nil
+}
+
+// MarkConversationRead marks every message in the conversation as read by
+// the user.
+func MarkConversationRead(conversationID, userID uint) error {
+	result := database.Database.Model(&model.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Update("last_read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotParticipant
+	}
+
+	clearEmailDebounce(userID, conversationEmailKey(conversationID))
+	return nil
+}
+
+// CanReportMessage reports whether the user may see, and so report, the
+// message.
+func CanReportMessage(message model.Message, userID uint) bool {
+	_, err := GetConversation(message.ConversationID, userID)
+	return err == nil && message.UserID != userID
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFollowSelf = errors.New("you cannot follow yourself")
+
+// FollowUser follows a user, or updates whether an existing follow notifies
+// about new threads.
+func FollowUser(followerID, userID uint, notify bool) (model.Follow, error) {
+	if followerID == userID {
+		return model.Follow{}, ErrFollowSelf
+	}
+
+	if err := database.Database.Where("id = ? AND status = ?", userID, model.UserStatusActive).First(&model.User{}).Error; err != nil {
+		return model.Follow{}, err
+	}
+
+	follow := model.Follow{FollowerID: followerID, FollowingID: &userID, Notify: notify}
+	err := database.Database.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "follower_id"}, {Name: "following_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "following_id IS NOT NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"notify", "updated_at"}),
+	}).Create(&follow).Error
+	if err != nil {
+		return follow, err
+	}
+
+	err = database.Database.First(&follow, "follower_id = ? AND following_id = ?", followerID, userID).Error
+	return follow, err
+}
+
+func FollowCategory(followerID, categoryID uint) (model.Follow, error) {
+	if err := database.Database.First(&model.Category{}, categoryID).Error; err != nil {
+		return model.Follow{}, err
+	}
+
+	follow := model.Follow{FollowerID: followerID, CategoryID: &categoryID}
+	err := database.Database.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "follower_id"}, {Name: "category_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "category_id IS NOT NULL"}}},
+		DoNothing:   true,
+	}).Create(&follow).Error
+	if err != nil {
+		return follow, err
+	}
+
+	err = database.Database.First(&follow, "follower_id = ? AND category_id = ?", followerID, categoryID).Error
+	return follow, err
+}
+
+// Unfollow removes a follow of the user or category given by column, which is
+// following_id or category_id.
+func Unfollow(followerID uint, column string, targetID uint) error {
+	result := database.Database.Unscoped().
+		Where("follower_id = ? AND "+column+" = ?", followerID, targetID).
+		Delete(&model.Follow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// NotifyFollowers tells the followers of the post's author who asked for it
+// that they started a thread.
+func NotifyFollowers(post model.Post) {
+	var followerIDs []uint
+	if err := database.Database.Model(&model.Follow{}).
+		Where("following_id = ? AND notify = ?", post.UserID, true).
+		Pluck("follower_id", &followerIDs).Error; err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, followerID := range followerIDs {
+		if err := CreateNotification(followerID, post.UserID, post.ID, model.NotificationTypeFollowedThread); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// GetFeed returns threads and replies by followed users and in followed
+// categories, newest first, starting after cursor, along with the cursor for
+// the next page. The next cursor is empty on the last page.
+func GetFeed(userID uint, cursor string, limit int) ([]model.Post, string, error) {
+	preferences, err := GetPreferences(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := database.Database.
+		Where("is_deleted = ? AND user_id <> ?", false, userID).
+		Where(database.Database.
+			Where("user_id IN (?)", database.Database.Model(&model.Follow{}).Select("following_id").Where("follower_id = ? AND following_id IS NOT NULL", userID)).
+			Or("category_id IN (?)", database.Database.Model(&model.Follow{}).Select("category_id").Where("follower_id = ? AND category_id IS NOT NULL", userID)))
+	query = ExcludeHiddenCategories(query, preferences)
+
+	if cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	var posts []model.Post
+	if err := query.
+		Preload("User").
+		Preload("Category").
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1).
+		Find(&posts).Error; err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[limit-1]
+		next = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	pageSize := PageSize(preferences)
+	for i := range posts {
+		if !posts[i].IsMasterPost {
+			posts[i].Page = utils.GetPostPage(posts[i], pageSize)
+		}
+	}
+
+	return posts, next, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidOAuthState = errors.New("invalid or expired state")
+var ErrIdentityAlreadyLinked = errors.New("this account is already linked to another user")
+var ErrLastLoginMethod = errors.New("cannot unlink the only way to sign in to this account")
+var ErrIdentityRegistered = errors.New("an account is already registered with this sign-in, sign in instead")
+
+// BeginExternalLogin remembers a new authorization request and returns the
+// URL to send the browser to. The request can only be completed together
+// with binding, which the requesting browser keeps in a cookie. Pass
+// linkUserID to link the provider to an existing account instead of signing
+// in.
+func BeginExternalLogin(providerName string, linkUserID *uint, binding string) (string, error) {
+	provider, err := GetIdentityProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state := utils.GenerateSecureToken(32)
+	oauthState := model.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: utils.GenerateSecureToken(32),
+		Nonce:        utils.GenerateSecureToken(16),
+		BindingHash:  utils.HashToken(binding),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+	}
+
+	authorizationURL, err := provider.AuthorizationURL(state, oauthState.Nonce, PKCEChallenge(oauthState.CodeVerifier))
+	if err != nil {
+		return "", err
+	}
+
+	if err := database.Database.Create(&oauthState).Error; err != nil {
+		return "", err
+	}
+
+	return authorizationURL, nil
+}
+
+// CompleteExternalLogin consumes the state from the callback, redeems the
+// authorization code and returns the verified identity along with the user
+// that started a linking flow, if any. A state with the wrong binding is left
+// untouched.
+func CompleteExternalLogin(ctx context.Context, providerName, code, state, binding string) (ExternalIdentity, *uint, error) {
+	provider, err := GetIdentityProvider(providerName)
+	if err != nil {
+		return ExternalIdentity{}, nil, err
+	}
+
+	var oauthState model.OAuthState
+	if err := database.Database.
+		Where("state_hash = ? AND provider = ?", utils.HashToken(state), providerName).
+		First(&oauthState).Error; err != nil {
+		return ExternalIdentity{}, nil, ErrInvalidOAuthState
+	}
+
+	if binding == "" || subtle.ConstantTimeCompare([]byte(oauthState.BindingHash), []byte(utils.HashToken(binding))) != 1 {
+		return ExternalIdentity{}, nil, ErrInvalidOAuthState
+	}
+
+	result := database.Database.Unscoped().Delete(&oauthState)
+	if result.Error != nil {
+		return ExternalIdentity{}, nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(oauthState.ExpiresAt) {
+		return ExternalIdentity{}, nil, ErrInvalidOAuthState
+	}
+
+	identity, err := provider.Exchange(ctx, code, oauthState.CodeVerifier, oauthState.Nonce)
+	if err != nil {
+		return ExternalIdentity{}, nil, err
+	}
+
+	return identity, oauthState.LinkUserID, nil
+}
+
+func FindIdentityUser(identity ExternalIdentity) (model.User, error) {
+	var user model.User
+	err := database.Database.
+		Joins("JOIN identities ON identities.user_id = users.id AND identities.deleted_at IS NULL").
+		Where("identities.provider = ? AND identities.subject = ?", identity.Provider, identity.Subject).
+		First(&user).Error
+	return user, err
+}
+
+func LinkIdentity(userID uint, identity ExternalIdentity) error {
+	var existing model.Identity
+	err := database.Database.
+		Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).
+		First(&existing).Error
+	if err == nil {
+		if existing.UserID != userID {
+			return ErrIdentityAlreadyLinked
+		}
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return database.Database.Create(&model.Identity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}).Error
+}
+
+// UnlinkIdentity removes a linked identity unless it is the user's last way
+// to sign in.
+func UnlinkIdentity(userID, identityID uint) error {
+	var user model.User
+	if err := database.Database.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	var identity model.Identity
+	if err := database.Database.Where("id = ? AND user_id = ?", identityID, userID).First(&identity).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	if err := database.Database.Model(&model.Identity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if user.PasswordHash == "" && count <= 1 {
+		return ErrLastLoginMethod
+	}
+
+	return database.Database.Unscoped().Delete(&identity).Error
+}
+
+// CreateUserFromIdentity registers a new account for a first-time external
+// sign-in. The account has no password until the user sets one through the
+// password reset flow. Registration modes apply as for Register. A
+// registration token that was already used fails with ErrIdentityRegistered.
+func CreateUserFromIdentity(identity ExternalIdentity, username, email, inviteCode string) (model.User, error) {
+	randomAvatar := utils.GetRandomAvatar()
+	user := model.User{
+		Username:  username,
+		Email:     email,
+		AvatarURL: &randomAvatar,
+	}
+
+	if identity.EmailVerified && identity.Email == email {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Identity{}).Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrIdentityRegistered
+		}
+
+		if err := createUser(tx, &user, inviteCode); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.Identity{
+			UserID:   user.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}).Error
+	})
+
+	return user, err
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"onichan/database"
+	"onichan/model"
+	"os"
+	"strconv"
+	"time"
+)
+
+var MAGIC_LINK_ENABLED bool
+var MAGIC_LINK_TTL int
+
+func LoadMagicLinkEnv() {
+	MAGIC_LINK_ENABLED, _ = strconv.ParseBool(os.Getenv("MAGIC_LINK_ENABLED"))
+	MAGIC_LINK_TTL, _ = strconv.Atoi(os.Getenv("MAGIC_LINK_TTL"))
+	if MAGIC_LINK_TTL <= 0 {
+		MAGIC_LINK_TTL = 15
+	}
+}
+
+// SendMagicLink mails a sign-in link that only works together with nonce,
+// which the requesting browser keeps in a cookie.
+func SendMagicLink(user model.User, nonce string) error {
+	token, err := IssueBoundUserToken(user.ID, model.TokenPurposeMagicLink, time.Duration(MAGIC_LINK_TTL)*time.Minute, nonce)
+	if err != nil {
+		return err
+	}
+
+	return SendEmail(user.Email, "Your sign-in link", "Click the link to sign in. It works once, within "+strconv.Itoa(MAGIC_LINK_TTL)+" minutes, and only in the browser you requested it from: "+os.Getenv("FRONTEND_URL")+"/magic-link?token="+token)
+}
+
+// ConsumeMagicLink redeems a sign-in link and returns its owner. Following
+// the link proves the owner reads the account's email, so it is marked
+// verified.
+func ConsumeMagicLink(rawToken, nonce string) (model.User, error) {
+	var user model.User
+
+	token, err := ConsumeBoundUserToken(rawToken, model.TokenPurposeMagicLink, nonce)
+	if err != nil {
+		return user, err
+	}
+
+	if err := database.Database.First(&user, token.UserID).Error; err != nil {
+		return user, ErrInvalidUserToken
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := MarkEmailVerified(&user); err != nil {
+			return user, err
+		}
+	}
+
+	return user, nil
+}
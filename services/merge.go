@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"onichan/websocket"
+
+	"gorm.io/gorm"
+)
+
+var ErrMergeSameUser = errors.New("cannot merge an account into itself")
+
+// MergeUsers folds the duplicate account source into target. Posts,
+// reactions, notifications, reports and read threads move over to target,
+// then source is deleted like a closed account, all in one transaction. Reactions both accounts gave
+// the same post are kept once.
+func MergeUsers(target model.User, source model.User) error {
+	if target.ID == source.ID {
+		return ErrMergeSameUser
+	}
+	if isLastAdmin(source) {
+		return ErrLastAdmin
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.Post{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().
+			Where("user_id = ? AND EXISTS (SELECT 1 FROM post_reactions AS kept WHERE kept.user_id = ? AND kept.post_id = post_reactions.post_id AND kept.reaction_id = post_reactions.reaction_id)", source.ID, target.ID).
+			Delete(&model.PostReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.PostReaction{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&model.Notification{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Notification{}).Where("from_user_id = ?", source.ID).UpdateColumn("from_user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&model.Report{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ? AND post_id IN (?)", source.ID, tx.Model(&model.PostView{}).Select("post_id").Where("user_id = ?", target.ID)).Delete(&model.PostView{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.PostView{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		return anonymizeAccount(tx, source)
+	})
+	if err != nil {
+		return err
+	}
+
+	websocket.DisconnectUser(source.ID)
+	removeAvatarUploads(source.ID, "")
+
+	// Reactions between the two accounts no longer count, and duplicates are
+	// gone, so the reputation of both target and other authors can change.
+	// The merge itself is done, so failures here are only logged.
+	if err := RecalculateReputation(); err != nil {
+		fmt.Println(err)
+	}
+	if err := database.Database.First(&target, target.ID).Error; err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	if err := UpdateTrustLevel(target); err != nil {
+		fmt.Println(err)
+	}
+	return nil
+}
@@ -1,13 +1,81 @@
 package services
 
 import (
+	"fmt"
 	"onichan/database"
 	"onichan/model"
 	"onichan/utils"
 	"onichan/websocket"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm/clause"
 )
 
+// NOTIFICATION_EMAIL_INTERVAL is how many minutes pass before a user is
+// emailed again about the same conversation or thread they have not read.
+var NOTIFICATION_EMAIL_INTERVAL int
+
+func LoadNotificationEnv() {
+	var err error
+	NOTIFICATION_EMAIL_INTERVAL, err = strconv.Atoi(os.Getenv("NOTIFICATION_EMAIL_INTERVAL"))
+	if err != nil || NOTIFICATION_EMAIL_INTERVAL < 0 {
+		NOTIFICATION_EMAIL_INTERVAL = 15
+	}
+}
+
+// claimEmail reports whether the user may be emailed about key now, and if
+// so records the email. Users with an open websocket already got the update
+// and are not emailed.
+func claimEmail(userID uint, key string) bool {
+	if websocket.IsConnected(userID) {
+		return false
+	}
+	return debounceEmail(userID, key, time.Duration(NOTIFICATION_EMAIL_INTERVAL)*time.Minute)
+}
+
+// debounceEmail reports whether the last email to the user about key is
+// older than interval, and if so records a new one. The upsert only touches
+// the row when the previous email is old enough, so concurrent senders
+// cannot both claim it.
+func debounceEmail(userID uint, key string, interval time.Duration) bool {
+	now := time.Now()
+	result := database.Database.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"sent_at": now}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "email_debounces", Name: "sent_at"}, Value: now.Add(-interval)},
+		}},
+	}).Create(&model.EmailDebounce{UserID: userID, Key: key, SentAt: now})
+	if result.Error != nil {
+		fmt.Println(result.Error)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+// clearEmailDebounce lets the user be emailed right away about the keys
+// matching pattern again, once they have caught up on them.
+func clearEmailDebounce(userID uint, pattern string) {
+	if err := database.Database.Where("user_id = ? AND key LIKE ?", userID, pattern).Delete(&model.EmailDebounce{}).Error; err != nil {
+		fmt.Println(err)
+	}
+}
+
+// ClearNotificationEmails is called once the user read their notifications,
+// so the next reply in any thread emails them again.
+func ClearNotificationEmails(userID uint) {
+	clearEmailDebounce(userID, "thread:%")
+}
+
+// CreateNotification stores and pushes a notification, unless forUser muted
+// or blocked fromUser.
 func CreateNotification(forUser, fromUser uint, postID uint, notificationType string) error {
+	if IsSilenced(forUser, fromUser) {
+		return nil
+	}
+
 	notification := model.Notification{
 		UserID:           forUser,
 		FromUserID:       fromUser,
@@ -20,9 +88,57 @@ func CreateNotification(forUser, fromUser uint, postID uint, notificationType st
 	}
 
 	database.Database.Model(&notification).Preload("FromUser").Preload("Post").Preload("Post.Category").First(&notification)
-	notification.Post.Page = utils.GetPostPage(notification.Post)
+	preferences, err := GetPreferences(forUser)
+	if err != nil {
+		preferences = DefaultPreferences(forUser)
+	}
+	notification.Post.Page = utils.GetPostPage(notification.Post, PageSize(preferences))
 
 	websocket.SendWebSocketNotification(forUser, notification)
 
+	if ((notificationType == "reply" || notificationType == "comment") && preferences.EmailOnReply) ||
+		(notificationType == model.NotificationTypeFollowedThread && preferences.EmailOnFollowedThread) {
+		go emailNotification(notification)
+	}
+
 	return nil
 }
+
+// emailNotification sends the notification to users who asked for emails,
+// at most once per thread every NOTIFICATION_EMAIL_INTERVAL minutes.
+func emailNotification(notification model.Notification) {
+	threadID := notification.PostID
+	if notification.Post.ParentPostID != nil {
+		threadID = *notification.Post.ParentPostID
+	}
+	if !claimEmail(notification.UserID, "thread:"+strconv.Itoa(int(threadID))) {
+		return
+	}
+
+	var user model.User
+	if err := database.Database.First(&user, notification.UserID).Error; err != nil {
+		fmt.Println(err)
+		return
+	}
+	link := os.Getenv("FRONTEND_URL") + "/posts/" + strconv.Itoa(int(threadID)) + "?page=" + strconv.Itoa(notification.Post.Page)
+
+	subject := notification.FromUser.Username + " replied to your post"
+	if notification.NotificationType == model.NotificationTypeFollowedThread {
+		subject = notification.FromUser.Username + " started a new thread"
+	}
+
+	if err := SendEmail(user.Email, subject, subject+": "+link); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// SignalNewPost tells users viewing the thread about a new post, except
+// those who muted or blocked its author.
+func SignalNewPost(postID, authorID uint) {
+	silenced, err := SilencedBy(authorID)
+	if err != nil {
+		silenced = map[uint]bool{}
+	}
+
+	websocket.SendNewPostSignal(postID, authorID, silenced)
+}
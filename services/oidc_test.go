@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"onichan/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// stubAuthorization is an authorization request the stub provider approved
+// and can redeem once.
+type stubAuthorization struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// stubOIDCServer is a minimal OpenID Connect provider for tests. It serves
+// discovery, its JWKS, an authorization endpoint that approves every request
+// for Subject, and a token endpoint that checks the client credentials and
+// the PKCE verifier before issuing a signed id token.
+type stubOIDCServer struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	Subject      string
+	Email        string
+
+	key *rsa.PrivateKey
+	mu  sync.Mutex
+	// codes holds the issued authorization codes until they are redeemed.
+	codes map[string]stubAuthorization
+}
+
+func newStubOIDCServer(t *testing.T) *stubOIDCServer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubOIDCServer{
+		ClientID:     "onichan",
+		ClientSecret: "secret",
+		Subject:      "stub-user-1",
+		Email:        "stub@example.com",
+		key:          key,
+		codes:        make(map[string]stubAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", stub.discovery)
+	mux.HandleFunc("/jwks", stub.jwks)
+	mux.HandleFunc("/authorize", stub.authorize)
+	mux.HandleFunc("/token", stub.token)
+	stub.Server = httptest.NewServer(mux)
+	t.Cleanup(stub.Close)
+
+	return stub
+}
+
+func (s *stubOIDCServer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(oidcDiscovery{
+		Issuer:                s.URL,
+		AuthorizationEndpoint: s.URL + "/authorize",
+		TokenEndpoint:         s.URL + "/token",
+		JWKSURI:               s.URL + "/jwks",
+	})
+}
+
+func (s *stubOIDCServer) jwks(w http.ResponseWriter, r *http.Request) {
+	jwk, err := utils.NewJWK("stub-key", "RS256", &s.key.PublicKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(utils.JWKS{Keys: []utils.JWK{jwk}})
+}
+
+func (s *stubOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := utils.GenerateSecureToken(16)
+	s.mu.Lock()
+	s.codes[code] = stubAuthorization{
+		clientID:      query.Get("client_id"),
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *stubOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	authorization, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	if !ok ||
+		r.PostForm.Get("client_id") != s.ClientID ||
+		r.PostForm.Get("client_secret") != s.ClientSecret ||
+		r.PostForm.Get("client_id") != authorization.clientID ||
+		r.PostForm.Get("redirect_uri") != authorization.redirectURI ||
+		PKCEChallenge(r.PostForm.Get("code_verifier")) != authorization.codeChallenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.URL,
+		"aud":            s.ClientID,
+		"sub":            s.Subject,
+		"email":          s.Email,
+		"email_verified": true,
+		"nonce":          authorization.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = "stub-key"
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+// Provider returns an OIDCProvider configured for the stub.
+func (s *stubOIDCServer) Provider() *OIDCProvider {
+	return &OIDCProvider{
+		ProviderName: "stub",
+		Issuer:       s.URL,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  "http://localhost:3000/oauth/stub/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+// authorizeCode follows the authorization URL like a browser would and
+// returns the code and state the provider redirected back with.
+func authorizeCode(t *testing.T, authorizationURL string) (string, string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := client.Get(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned status %d", response.StatusCode)
+	}
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	stub := newStubOIDCServer(t)
+	provider := stub.Provider()
+	ctx := context.Background()
+
+	verifier := utils.GenerateSecureToken(32)
+	nonce := utils.GenerateSecureToken(16)
+	authorizationURL, err := provider.AuthorizationURL("state-1", nonce, PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state := authorizeCode(t, authorizationURL)
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+
+	identity, err := provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Provider != "stub" || identity.Subject != stub.Subject || identity.Email != stub.Email || !identity.EmailVerified {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+
+	if _, err := provider.Exchange(ctx, code, verifier, nonce); err == nil {
+		t.Fatal("redeeming a code twice succeeded")
+	}
+}
+
+func TestOIDCRejectsWrongVerifierAndNonce(t *testing.T) {
+	stub := newStubOIDCServer(t)
+	provider := stub.Provider()
+	ctx := context.Background()
+
+	verifier := utils.GenerateSecureToken(32)
+	nonce := utils.GenerateSecureToken(16)
+
+	authorizationURL, err := provider.AuthorizationURL("state", nonce, PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := authorizeCode(t, authorizationURL)
+	if _, err := provider.Exchange(ctx, code, utils.GenerateSecureToken(32), nonce); err == nil {
+		t.Fatal("exchange with the wrong code verifier succeeded")
+	}
+
+	authorizationURL, err = provider.AuthorizationURL("state", nonce, PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ = authorizeCode(t, authorizationURL)
+	if _, err := provider.Exchange(ctx, code, verifier, "other-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("exchange with the wrong nonce: err = %v, want ErrInvalidIDToken", err)
+	}
+}
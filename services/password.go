@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"os"
+	"strconv"
+	"time"
+)
+
+var PASSWORD_RESET_TTL int
+
+func LoadPasswordResetEnv() {
+	PASSWORD_RESET_TTL, _ = strconv.Atoi(os.Getenv("EMAIL_EXPIRATION"))
+}
+
+// SetPassword replaces the user's password. Any password reset link that is
+// still outstanding stops working.
+func SetPassword(user model.User, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := database.Database.Model(&user).Updates(map[string]interface{}{
+		"password_hash": hashedPassword,
+		"salt":          "",
+	}).Error; err != nil {
+		return err
+	}
+
+	return InvalidateUserTokens(user.ID, model.TokenPurposePasswordReset)
+}
+
+// CheckPassword reports whether password is the user's password. Hashes in an
+// old format or with outdated Argon2id parameters are replaced on success, so
+// accounts migrate as people sign in.
+func CheckPassword(user model.User, password string) bool {
+	match, needsRehash, err := utils.VerifyPassword(user.PasswordHash, user.Salt, password)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	if match && needsRehash {
+		if hashedPassword, err := utils.HashPassword(password); err == nil {
+			database.Database.Model(&user).Updates(map[string]interface{}{
+				"password_hash": hashedPassword,
+				"salt":          "",
+			})
+		}
+	}
+
+	return match
+}
+
+func SendPasswordResetEmail(user model.User) error {
+	token, err := IssueUserToken(user.ID, model.TokenPurposePasswordReset, time.Duration(PASSWORD_RESET_TTL)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	return SendEmail(user.Email, "Change Password", "Click the link to change your password: "+os.Getenv("FRONTEND_URL")+"/reset-password?token="+token)
+}
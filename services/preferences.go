@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bounds for the page size users can choose.
+const (
+	MinPageSize = 5
+	MaxPageSize = 100
+)
+
+var (
+	ErrInvalidPageSize   = fmt.Errorf("page size must be between %d and %d, or 0 for the default", MinPageSize, MaxPageSize)
+	ErrInvalidThreadSort = errors.New("thread sort must be activity, newest or oldest")
+	ErrInvalidTimezone   = errors.New("unknown timezone")
+	ErrInvalidLocale     = errors.New("locale must look like en or en-US")
+	ErrInvalidCategories = errors.New("muted categories must be existing categories")
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// DefaultPreferences are used until the user saves their own.
+func DefaultPreferences(userID uint) model.UserPreferences {
+	return model.UserPreferences{
+		UserID:           userID,
+		ThreadSort:       model.ThreadSortActivity,
+		Timezone:         "UTC",
+		Locale:           "en",
+		MutedCategoryIDs: []uint{},
+	}
+}
+
+func GetPreferences(userID uint) (model.UserPreferences, error) {
+	var preferences model.UserPreferences
+	err := database.Database.First(&preferences, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultPreferences(userID), nil
+	}
+	return preferences, err
+}
+
+// ValidatePreferences checks the preferences and drops duplicate muted
+// categories. Every muted category has to exist, which also caps the list at
+// the number of categories.
+func ValidatePreferences(preferences *model.UserPreferences) error {
+	if preferences.PageSize != 0 && (preferences.PageSize < MinPageSize || preferences.PageSize > MaxPageSize) {
+		return ErrInvalidPageSize
+	}
+	switch preferences.ThreadSort {
+	case model.ThreadSortActivity, model.ThreadSortNewest, model.ThreadSortOldest:
+	default:
+		return ErrInvalidThreadSort
+	}
+	if _, err := time.LoadLocation(preferences.Timezone); err != nil || preferences.Timezone == "" || preferences.Timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+	if !localePattern.MatchString(preferences.Locale) {
+		return ErrInvalidLocale
+	}
+
+	muted := []uint{}
+	seen := map[uint]bool{}
+	for _, id := range preferences.MutedCategoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			muted = append(muted, id)
+		}
+	}
+	preferences.MutedCategoryIDs = muted
+
+	if len(muted) > 0 {
+		var count int64
+		if err := database.Database.Model(&model.Category{}).Where("id IN ?", muted).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(muted) {
+			return ErrInvalidCategories
+		}
+	}
+	return nil
+}
+
+func SavePreferences(preferences *model.UserPreferences) error {
+	if err := ValidatePreferences(preferences); err != nil {
+		return err
+	}
+
+	return database.Database.Clauses(clause.OnConflict{UpdateAll: true}).Create(preferences).Error
+}
+
+// PageSize returns the page size the user chose, or PAGE_SIZE.
+func PageSize(preferences model.UserPreferences) int {
+	if preferences.PageSize != 0 {
+		return preferences.PageSize
+	}
+	pageSize, _ := strconv.Atoi(os.Getenv("PAGE_SIZE"))
+	return pageSize
+}
+
+// ThreadOrder returns the ORDER BY clause for the thread sort.
+func ThreadOrder(sort string) string {
+	switch sort {
+	case model.ThreadSortNewest:
+		return "created_at DESC"
+	case model.ThreadSortOldest:
+		return "created_at ASC"
+	default:
+		return "last_updated DESC"
+	}
+}
+
+// ExcludeHiddenCategories leaves out posts in the categories the user muted
+// and, when they hide NSFW content, posts in NSFW categories.
+func ExcludeHiddenCategories(query *gorm.DB, preferences model.UserPreferences) *gorm.DB {
+	if len(preferences.MutedCategoryIDs) > 0 {
+		query = query.Where("category_id NOT IN ?", preferences.MutedCategoryIDs)
+	}
+	if preferences.HideNSFW {
+		query = query.Where("category_id NOT IN (?)", database.Database.Model(&model.Category{}).Select("id").Where("nsfw = ?", true))
+	}
+	return query
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RegistrationModeOpen = "open"
+	// RegistrationModeInvite requires an invite code to register.
+	RegistrationModeInvite = "invite"
+	// RegistrationModeApproval puts new accounts in a queue for admins to
+	// approve. Registering with an invite skips the queue.
+	RegistrationModeApproval = "approval"
+)
+
+// Limits for invites created by regular users. Staff are not limited.
+const (
+	maxUserInviteUses    = 10
+	maxUserInviteDays    = 30
+	maxUserActiveInvites = 5
+)
+
+var ErrInviteRequired = errors.New("an invite code is required to register")
+var ErrInvalidInvite = errors.New("invalid or expired invite code")
+var ErrInviteNotAllowed = errors.New("you are not allowed to create invites yet")
+var ErrInviteLimit = errors.New("invite exceeds the limits for regular users")
+
+// RegisterUser creates the account according to the registration mode,
+// redeeming inviteCode if given. Accounts that need approval are created with
+// the pending status.
+func RegisterUser(user *model.User, inviteCode string) error {
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		return createUser(tx, user, inviteCode)
+	})
+}
+
+func createUser(tx *gorm.DB, user *model.User, inviteCode string) error {
+	mode := GetSetting(SettingRegistrationMode)
+
+	var invite model.Invite
+	if inviteCode != "" {
+		if err := tx.Where("code_hash = ?", utils.HashToken(inviteCode)).First(&invite).Error; err != nil {
+			return ErrInvalidInvite
+		}
+		if !invite.UsableBy(user.Email) {
+			return ErrInvalidInvite
+		}
+		user.InvitedByID = &invite.CreatedByID
+	} else if mode == RegistrationModeInvite {
+		return ErrInviteRequired
+	}
+
+	user.Status = model.UserStatusActive
+	if mode == RegistrationModeApproval && inviteCode == "" {
+		user.Status = model.UserStatusPending
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.UnverifiedSince = &now
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+
+	if invite.ID != 0 {
+		// Checked again here so concurrent registrations cannot overuse it.
+		result := tx.Model(&model.Invite{}).
+			Where("id = ? AND uses < max_uses AND revoked_at IS NULL", invite.ID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidInvite
+		}
+	}
+
+	return nil
+}
+
+// CanCreateInvites reports whether the user may invite people: staff with
+// the manage_registrations permission, or verified members whose account is
+// at least invite_min_account_days old.
+func CanCreateInvites(user model.User) bool {
+	if HasPermission(user.ID, user.Role, model.PermissionManageRegistrations, nil) {
+		return true
+	}
+
+	minDays := GetIntSetting(SettingInviteMinAccountDays)
+	if minDays < 0 || user.EmailVerifiedAt == nil {
+		return false
+	}
+
+	return time.Since(user.CreatedAt) >= time.Duration(minDays)*24*time.Hour
+}
+
+// CreateInvite creates an invite and returns it with its code, which is not
+// stored and cannot be shown again. A ttl of zero never expires.
+func CreateInvite(user model.User, maxUses int, ttl time.Duration, email *string) (model.Invite, string, error) {
+	if !CanCreateInvites(user) {
+		return model.Invite{}, "", ErrInviteNotAllowed
+	}
+
+	if !HasPermission(user.ID, user.Role, model.PermissionManageRegistrations, nil) {
+		var active int64
+		database.Database.Model(&model.Invite{}).
+			Where("created_by_id = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)", user.ID, time.Now()).
+			Count(&active)
+
+		if maxUses > maxUserInviteUses || ttl <= 0 || ttl > maxUserInviteDays*24*time.Hour || active >= maxUserActiveInvites {
+			return model.Invite{}, "", ErrInviteLimit
+		}
+	}
+
+	code := utils.GenerateSecureToken(12)
+	invite := model.Invite{
+		CodeHash:    utils.HashToken(code),
+		Prefix:      code[:6],
+		CreatedByID: user.ID,
+		MaxUses:     maxUses,
+	}
+	if email != nil && *email != "" {
+		lower := strings.ToLower(*email)
+		invite.Email = &lower
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := database.Database.Create(&invite).Error; err != nil {
+		return invite, "", err
+	}
+
+	return invite, code, nil
+}
+
+func RevokeInvite(invite model.Invite) error {
+	return database.Database.Model(&invite).Update("revoked_at", time.Now()).Error
+}
+
+// ApproveRegistration activates a pending account and tells its owner. A
+// failure to send the email is only logged.
+func ApproveRegistration(user model.User) error {
+	updates := map[string]interface{}{"status": model.UserStatusActive}
+	if user.UnverifiedSince != nil {
+		// The time to verify starts once the account can be used.
+		updates["unverified_since"] = time.Now()
+	}
+	if err := database.Database.Model(&user).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if err := SendEmail(user.Email, "Your registration has been approved", "Welcome! Your account "+user.Username+" has been approved and you can now sign in: "+os.Getenv("FRONTEND_URL")+"/login"); err != nil {
+		fmt.Println(err)
+	}
+
+	return nil
+}
+
+// RejectRegistration deletes a pending account, releasing its username and
+// email, and tells its owner why.
+func RejectRegistration(user model.User, reason string) error {
+	if err := database.Database.Transaction(func(tx *gorm.DB) error {
+		return deleteUsers(tx, []uint{user.ID})
+	}); err != nil {
+		return err
+	}
+	removeAvatarUploads(user.ID, "")
+
+	body := "Sorry, your registration as " + user.Username + " has not been approved."
+	if reason != "" {
+		body += "\n\nReason: " + reason
+	}
+
+	if err := SendEmail(user.Email, "Your registration has been declined", body); err != nil {
+		fmt.Println(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"onichan/database"
+	"onichan/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AddReaction stores a reaction and credits its weight to the post's author.
+// Reactions to one's own posts do not count.
+func AddReaction(postReaction *model.PostReaction, post model.Post) error {
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(postReaction).Error; err != nil {
+			return err
+		}
+		return adjustReactionReputation(tx, *postReaction, post, 1)
+	})
+}
+
+// RemoveReaction deletes a reaction and takes its weight back from the post's
+// author.
+func RemoveReaction(postReaction model.PostReaction, post model.Post) error {
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&postReaction).Error; err != nil {
+			return err
+		}
+		return adjustReactionReputation(tx, postReaction, post, -1)
+	})
+}
+
+func adjustReactionReputation(tx *gorm.DB, postReaction model.PostReaction, post model.Post, sign int) error {
+	if postReaction.UserID == post.UserID {
+		return nil
+	}
+
+	var reaction model.Reaction
+	if err := tx.First(&reaction, postReaction.ReactionID).Error; err != nil {
+		return err
+	}
+	if reaction.Weight == 0 {
+		return nil
+	}
+
+	return tx.Model(&model.User{}).
+		Where("id = ?", post.UserID).
+		Update("reputation", gorm.Expr("reputation + ?", sign*reaction.Weight)).Error
+}
+
+// RemovePost replaces a post removed by a moderator and applies the
+// reputation penalty to its author, once per post.
+func RemovePost(post *model.Post) error {
+	if post.RemovedAt != nil {
+		return nil
+	}
+
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		post.Content = "[This post has been deleted by a moderator]"
+		post.IsDeleted = true
+		post.RemovedAt = &now
+
+		if post.Title != nil {
+			*post.Title = "[This post has been deleted by a moderator]"
+		}
+
+		if err := tx.Save(post).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.User{}).
+			Where("id = ?", post.UserID).
+			Update("reputation", gorm.Expr("reputation - ?", GetIntSetting(SettingRemovedPostPenalty))).Error
+	})
+}
+
+// RecalculateReputation recomputes every user's reputation from scratch with
+// the current reaction weights and removed post penalty.
+func RecalculateReputation() error {
+	return database.Database.Exec(`UPDATE users SET reputation = COALESCE((
+			SELECT SUM(reactions.weight)
+			FROM post_reactions
+			JOIN posts ON posts.id = post_reactions.post_id
+			JOIN reactions ON reactions.id = post_reactions.reaction_id
+			WHERE posts.user_id = users.id AND post_reactions.user_id <> users.id
+				AND post_reactions.deleted_at IS NULL AND posts.deleted_at IS NULL
+		), 0) - ? * (
+			SELECT COUNT(*) FROM posts
+			WHERE posts.user_id = users.id AND posts.removed_at IS NOT NULL AND posts.deleted_at IS NULL
+		)`, GetIntSetting(SettingRemovedPostPenalty)).Error
+}
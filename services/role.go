@@ -0,0 +1,410 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+var ErrSystemRole = errors.New("system roles cannot be renamed or deleted, and admin keeps every permission")
+var ErrRoleInUse = errors.New("role is still assigned to users")
+var ErrLastAdmin = errors.New("cannot remove the last user with every permission")
+var ErrPermissionNotHeld = errors.New("cannot grant permissions you do not hold")
+var ErrTargetMorePrivileged = errors.New("cannot act on a user with permissions you do not hold")
+
+// DefaultRoles are created by the migration when missing. The admin role
+// always has every permission; the others can be edited.
+var DefaultRoles = []model.Role{
+	{Name: "admin", Description: "Full access", Permissions: []string{model.PermissionAll}, IsSystem: true},
+	{Name: "moderator", Description: "Handles reports and moderates posts", Permissions: []string{
+		model.PermissionResolveReports,
+		model.PermissionDeleteAnyPost,
+		model.PermissionEditAnyPost,
+		model.PermissionBanUsers,
+	}},
+	{Name: "user", Description: "Regular member", Permissions: []string{}, IsSystem: true},
+}
+
+func SeedRoles() error {
+	for _, role := range DefaultRoles {
+		if err := database.Database.Where("name = ?", role.Name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetRole(name string) (model.Role, error) {
+	var role model.Role
+	err := database.Database.First(&role, "name = ?", name).Error
+	return role, err
+}
+
+func ValidatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		valid := permission == model.PermissionAll
+		for _, p := range model.Permissions {
+			if p == permission {
+				valid = true
+			}
+		}
+		if !valid {
+			return ErrUnknownPermission
+		}
+	}
+	return nil
+}
+
+// HasPermission reports whether the user holds permission through their
+// global role or, when categoryID is given, through a role assigned to them
+// for that category.
+func HasPermission(userID uint, roleName, permission string, categoryID *uint) bool {
+	if role, err := GetRole(roleName); err == nil && role.HasPermission(permission) {
+		return true
+	}
+
+	if categoryID == nil {
+		return false
+	}
+
+	var assignments []model.RoleAssignment
+	database.Database.Preload("Role").Where("user_id = ? AND category_id = ?", userID, *categoryID).Find(&assignments)
+	for _, assignment := range assignments {
+		if assignment.Role.HasPermission(permission) && isCategoryPermission(permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasPermissionAnywhere reports whether the user holds permission globally or
+// in at least one category.
+func HasPermissionAnywhere(userID uint, roleName, permission string) bool {
+	if HasPermission(userID, roleName, permission, nil) {
+		return true
+	}
+
+	categoryIDs, err := PermissionCategories(userID, permission)
+	return err == nil && len(categoryIDs) > 0
+}
+
+// IsStaff reports whether the user holds any permission at all, globally or
+// in a category.
+func IsStaff(userID uint, roleName string) bool {
+	if role, err := GetRole(roleName); err == nil && len(role.Permissions) > 0 {
+		return true
+	}
+
+	var count int64
+	database.Database.Model(&model.RoleAssignment{}).Where("user_id = ?", userID).Count(&count)
+	return count > 0
+}
+
+// PermissionCategories returns the categories in which the user holds
+// permission through a category role assignment.
+func PermissionCategories(userID uint, permission string) ([]uint, error) {
+	if !isCategoryPermission(permission) {
+		return nil, nil
+	}
+
+	var assignments []model.RoleAssignment
+	if err := database.Database.Preload("Role").Where("user_id = ?", userID).Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	var categoryIDs []uint
+	for _, assignment := range assignments {
+		if assignment.Role.HasPermission(permission) {
+			categoryIDs = append(categoryIDs, assignment.CategoryID)
+		}
+	}
+
+	return categoryIDs, nil
+}
+
+// checkGrant makes sure the acting user holds every permission they are about
+// to hand out, globally or, when categoryID is given, in that category, so
+// nobody can grant themselves more than they have.
+func checkGrant(actorID uint, actorRole string, permissions []string, categoryID *uint) error {
+	for _, permission := range permissions {
+		if !HasPermission(actorID, actorRole, permission, categoryID) {
+			return ErrPermissionNotHeld
+		}
+	}
+	return nil
+}
+
+// CanManageUser checks that the acting user holds every permission the target
+// has, through their global role or any category assignment, so moderators
+// cannot act on admins. The last user with every permission is off limits.
+func CanManageUser(actorID uint, actorRole string, target model.User) error {
+	if isLastAdmin(target) {
+		return ErrLastAdmin
+	}
+	return checkTarget(actorID, actorRole, target)
+}
+
+// checkTarget is the permission part of CanManageUser.
+func checkTarget(actorID uint, actorRole string, target model.User) error {
+	if role, err := GetRole(target.Role); err == nil {
+		if checkGrant(actorID, actorRole, role.Permissions, nil) != nil {
+			return ErrTargetMorePrivileged
+		}
+	}
+
+	var assignments []model.RoleAssignment
+	if err := database.Database.Preload("Role").Where("user_id = ?", target.ID).Find(&assignments).Error; err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		for _, permission := range model.CategoryPermissions {
+			if assignment.Role.HasPermission(permission) && !HasPermission(actorID, actorRole, permission, &assignment.CategoryID) {
+				return ErrTargetMorePrivileged
+			}
+		}
+	}
+	return nil
+}
+
+// categoryGrants returns the category permissions the role gives when it is
+// assigned in a category.
+func categoryGrants(role model.Role) []string {
+	var granted []string
+	for _, permission := range model.CategoryPermissions {
+		if role.HasPermission(permission) {
+			granted = append(granted, permission)
+		}
+	}
+	return granted
+}
+
+// checkRoleHolders makes sure the acting user may manage everyone holding the
+// role, globally or through a category assignment.
+func checkRoleHolders(actorID uint, actorRole string, role model.Role) error {
+	var holders []model.User
+	if err := database.Database.
+		Where("role = ? OR id IN (?)", role.Name, database.Database.Model(&model.RoleAssignment{}).Select("user_id").Where("role_id = ?", role.ID)).
+		Find(&holders).Error; err != nil {
+		return err
+	}
+
+	for _, holder := range holders {
+		if err := checkTarget(actorID, actorRole, holder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isCategoryPermission(permission string) bool {
+	for _, p := range model.CategoryPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+	err := database.Database.Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// CreateRole creates a role with permissions the acting user holds.
+func CreateRole(role model.Role, actorID uint, actorRole string) (model.Role, error) {
+	if err := ValidatePermissions(role.Permissions); err != nil {
+		return role, err
+	}
+	if err := checkGrant(actorID, actorRole, role.Permissions, nil); err != nil {
+		return role, err
+	}
+
+	role.IsSystem = false
+	err := database.Database.Create(&role).Error
+	return role, err
+}
+
+// UpdateRole changes a role's description and permissions, and its name
+// unless it is a system role. To change permissions, the acting user has to
+// hold every permission the role had and gets, and may not manage anyone
+// holding it who is more privileged than they are.
+func UpdateRole(role model.Role, name, description *string, permissions []string, actorID uint, actorRole string) (model.Role, error) {
+	oldName := role.Name
+
+	if name != nil && *name != role.Name {
+		if role.IsSystem {
+			return role, ErrSystemRole
+		}
+		role.Name = *name
+	}
+
+	if description != nil {
+		role.Description = *description
+	}
+
+	if permissions != nil {
+		if role.Name == "admin" {
+			return role, ErrSystemRole
+		}
+		if err := ValidatePermissions(permissions); err != nil {
+			return role, err
+		}
+		if err := checkGrant(actorID, actorRole, role.Permissions, nil); err != nil {
+			return role, err
+		}
+		if err := checkGrant(actorID, actorRole, permissions, nil); err != nil {
+			return role, err
+		}
+		if err := checkRoleHolders(actorID, actorRole, role); err != nil {
+			return role, err
+		}
+		role.Permissions = permissions
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if role.Name != oldName {
+			if err := tx.Model(&model.User{}).Where("role = ?", oldName).Update("role", role.Name).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&role).Error
+	})
+	return role, err
+}
+
+// DeleteRole removes an unused role and its category assignments. The acting
+// user has to hold every permission of the role.
+func DeleteRole(role model.Role, actorID uint, actorRole string) error {
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+	if err := checkGrant(actorID, actorRole, role.Permissions, nil); err != nil {
+		return err
+	}
+
+	var count int64
+	database.Database.Model(&model.User{}).Where("role = ?", role.Name).Count(&count)
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("role_id = ?", role.ID).Delete(&model.RoleAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&role).Error
+	})
+}
+
+// isLastAdmin reports whether the user is the only one left with every
+// permission.
+func isLastAdmin(user model.User) bool {
+	current, err := GetRole(user.Role)
+	if err != nil || !current.HasPermission(model.PermissionAll) {
+		return false
+	}
+
+	var fullRoles []string
+	var roles []model.Role
+	database.Database.Find(&roles)
+	for _, r := range roles {
+		if r.HasPermission(model.PermissionAll) {
+			fullRoles = append(fullRoles, r.Name)
+		}
+	}
+
+	var count int64
+	database.Database.Model(&model.User{}).Where("role IN ?", fullRoles).Count(&count)
+	return count <= 1
+}
+
+// SetUserRole changes the user's global role to one whose permissions the
+// acting user holds, as long as the user holds nothing the actor lacks. The
+// last user with every permission cannot be demoted, so the forum cannot lock
+// itself out.
+func SetUserRole(user model.User, roleName string, actorID uint, actorRole string) error {
+	role, err := GetRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTarget(actorID, actorRole, user); err != nil {
+		return err
+	}
+	if err := checkGrant(actorID, actorRole, role.Permissions, nil); err != nil {
+		return err
+	}
+
+	if !role.HasPermission(model.PermissionAll) && isLastAdmin(user) {
+		return ErrLastAdmin
+	}
+
+	return database.Database.Model(&user).Update("role", role.Name).Error
+}
+
+func ListRoleAssignments(userID uint) ([]model.RoleAssignment, error) {
+	var assignments []model.RoleAssignment
+	query := database.Database.Preload("Role").Preload("Category").Order("id")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Find(&assignments).Error
+	return assignments, err
+}
+
+// AssignRole grants the role within the category. The assigning user has to
+// hold the role's category permissions in that category.
+func AssignRole(userID, roleID, categoryID, assignedByID uint, assignedByRole string) (model.RoleAssignment, error) {
+	var role model.Role
+	if err := database.Database.First(&role, roleID).Error; err != nil {
+		return model.RoleAssignment{}, err
+	}
+
+	if err := checkGrant(assignedByID, assignedByRole, categoryGrants(role), &categoryID); err != nil {
+		return model.RoleAssignment{}, err
+	}
+
+	assignment := model.RoleAssignment{
+		UserID:       userID,
+		RoleID:       roleID,
+		CategoryID:   categoryID,
+		AssignedByID: assignedByID,
+	}
+
+	if err := database.Database.Create(&assignment).Error; err != nil {
+		return assignment, err
+	}
+
+	err := database.Database.Preload("Role").Preload("Category").First(&assignment, assignment.ID).Error
+	return assignment, err
+}
+
+// UnassignRole removes a category role assignment. The acting user has to
+// hold the role's category permissions in that category and may not manage
+// the assigned user if they are more privileged.
+func UnassignRole(assignmentID, actorID uint, actorRole string) error {
+	var assignment model.RoleAssignment
+	if err := database.Database.Preload("Role").Preload("User").First(&assignment, assignmentID).Error; err != nil {
+		return err
+	}
+	if err := checkGrant(actorID, actorRole, categoryGrants(assignment.Role), &assignment.CategoryID); err != nil {
+		return err
+	}
+	if err := checkTarget(actorID, actorRole, assignment.User); err != nil {
+		return err
+	}
+
+	result := database.Database.Unscoped().Delete(&model.RoleAssignment{}, assignmentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
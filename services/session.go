@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"os"
+	"strconv"
+	"time"
+)
+
+var REFRESH_TOKEN_TTL int
+
+func LoadSessionEnv() {
+	REFRESH_TOKEN_TTL, _ = strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL"))
+}
+
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// CreateSession starts a new server-side session for the user and returns
+// its first access/refresh token pair.
+func CreateSession(userID uint, userAgent, ipAddress string) (TokenPair, error) {
+	now := time.Now()
+	session := model.Session{
+		UserID:     userID,
+		UserAgent:  truncate(userAgent, 255),
+		IPAddress:  truncate(ipAddress, 63),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Duration(REFRESH_TOKEN_TTL) * time.Hour),
+	}
+
+	if err := database.Database.Create(&session).Error; err != nil {
+		return TokenPair{}, err
+	}
+
+	return issueTokenPair(session)
+}
+
+func issueTokenPair(session model.Session) (TokenPair, error) {
+	refreshToken := utils.GenerateSecureToken(32)
+
+	if err := database.Database.Create(&model.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}).Error; err != nil {
+		return TokenPair{}, err
+	}
+
+	accessToken, err := utils.GenerateJWT(session.UserID, session.ID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// RefreshSession rotates a refresh token. Every refresh token can be used
+// exactly once; presenting one that was already rotated revokes the session.
+func RefreshSession(rawToken, userAgent, ipAddress string) (TokenPair, error) {
+	var token model.RefreshToken
+	if err := database.Database.
+		Preload("Session").
+		Where("token_hash = ?", utils.HashToken(rawToken)).
+		First(&token).Error; err != nil {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if token.Session.RevokedAt != nil || now.After(token.ExpiresAt) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	result := database.Database.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		// Someone is replaying a token that was already rotated, so either the
+		// client or an attacker holds a stolen copy. Kill the whole session.
+		if err := RevokeSession(token.SessionID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	session := token.Session
+	if err := database.Database.Model(&session).Updates(map[string]interface{}{
+		"user_agent":   truncate(userAgent, 255),
+		"ip_address":   truncate(ipAddress, 63),
+		"last_seen_at": now,
+		"expires_at":   now.Add(time.Duration(REFRESH_TOKEN_TTL) * time.Hour),
+	}).Error; err != nil {
+		return TokenPair{}, err
+	}
+
+	return issueTokenPair(session)
+}
+
+func RevokeSession(sessionID uint) error {
+	return database.Database.Model(&model.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserSessions revokes every active session of the user except
+// exceptSessionID. Pass 0 to revoke all of them.
+func RevokeUserSessions(userID uint, exceptSessionID uint) error {
+	return database.Database.Model(&model.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptSessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func ListActiveSessions(userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+
+	err := database.Database.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
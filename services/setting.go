@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"strconv"
+
+	"gorm.io/gorm/clause"
+)
+
+const SettingRequireAdminTwoFactor = "require_admin_2fa"
+const SettingRegistrationMode = "registration_mode"
+const SettingInviteMinAccountDays = "invite_min_account_days"
+const SettingChallengeRegister = "challenge_register"
+const SettingChallengePosts = "challenge_posts"
+const SettingChallengeTrustedAccountDays = "challenge_trusted_account_days"
+const SettingRemovedPostPenalty = "removed_post_penalty"
+const SettingTrustRequirements = "trust_level_requirements"
+const SettingTrustLevelCreateThread = "trust_level_create_thread"
+const SettingTrustLevelUpload = "trust_level_upload"
+const SettingTrustLevelPostLinks = "trust_level_post_links"
+const SettingTrustLevelWeightedFlags = "trust_level_weighted_flags"
+
+var ErrUnknownSetting = errors.New("unknown setting")
+var ErrInvalidSettingValue = errors.New("invalid setting value")
+
+type settingDefinition struct {
+	Default  string
+	Validate func(value string) bool
+}
+
+func isBool(value string) bool {
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
+
+func isInt(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+func isOneOf(values ...string) func(string) bool {
+	return func(value string) bool {
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// settingDefinitions lists every setting admins may change.
+var settingDefinitions = map[string]settingDefinition{
+	SettingRequireAdminTwoFactor: {Default: "false", Validate: isBool},
+	SettingRegistrationMode:      {Default: RegistrationModeOpen, Validate: isOneOf(RegistrationModeOpen, RegistrationModeInvite, RegistrationModeApproval)},
+	// Regular users may create invites once their account is this many days
+	// old; -1 leaves invites to staff.
+	SettingInviteMinAccountDays: {Default: "30", Validate: isInt},
+	SettingChallengeRegister:    {Default: "false", Validate: isBool},
+	SettingChallengePosts:       {Default: "false", Validate: isBool},
+	// Verified users skip challenges once their account is this many days
+	// old; -1 leaves only staff exempt.
+	SettingChallengeTrustedAccountDays: {Default: "7", Validate: isInt},
+	// Reputation taken from an author when a moderator removes their post.
+	SettingRemovedPostPenalty: {Default: "5", Validate: isInt},
+	// A JSON list with the requirements for trust levels 1 to 3.
+	SettingTrustRequirements: {Default: defaultTrustRequirements, Validate: isTrustRequirements},
+	// The trust level needed to start threads, upload images, post links and
+	// have reports count double.
+	SettingTrustLevelCreateThread:  {Default: "1", Validate: isTrustLevel},
+	SettingTrustLevelUpload:        {Default: "1", Validate: isTrustLevel},
+	SettingTrustLevelPostLinks:     {Default: "1", Validate: isTrustLevel},
+	SettingTrustLevelWeightedFlags: {Default: "3", Validate: isTrustLevel},
+}
+
+func GetSetting(key string) string {
+	var setting model.Setting
+	if err := database.Database.First(&setting, "key = ?", key).Error; err != nil {
+		return settingDefinitions[key].Default
+	}
+	return setting.Value
+}
+
+func GetBoolSetting(key string) bool {
+	value, _ := strconv.ParseBool(GetSetting(key))
+	return value
+}
+
+func GetIntSetting(key string) int {
+	value, _ := strconv.Atoi(GetSetting(key))
+	return value
+}
+
+func ListSettings() (map[string]string, error) {
+	var stored []model.Setting
+	if err := database.Database.Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]string, len(settingDefinitions))
+	for key, definition := range settingDefinitions {
+		settings[key] = definition.Default
+	}
+	for _, setting := range stored {
+		if _, ok := settingDefinitions[setting.Key]; ok {
+			settings[setting.Key] = setting.Value
+		}
+	}
+
+	return settings, nil
+}
+
+func ValidateSetting(key, value string) error {
+	definition, ok := settingDefinitions[key]
+	if !ok {
+		return ErrUnknownSetting
+	}
+
+	if !definition.Validate(value) {
+		return ErrInvalidSettingValue
+	}
+
+	return nil
+}
+
+func SetSetting(key, value string) error {
+	if err := ValidateSetting(key, value); err != nil {
+		return err
+	}
+
+	return database.Database.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&model.Setting{Key: key, Value: value}).Error
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"onichan/database"
+	"onichan/model"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThrottleStore persists failure counters. Use the in-memory store for a
+// single node and the Postgres store when several nodes share the load.
+type ThrottleStore interface {
+	Get(key string) (model.LoginThrottle, bool, error)
+	Increment(key string, now time.Time) (model.LoginThrottle, error)
+	Lock(key string, until time.Time) error
+	Delete(key string) error
+	List() ([]model.LoginThrottle, error)
+	// DeleteStale removes the keys that went quiet before now and are not
+	// locked anymore.
+	DeleteStale(now time.Time) (int64, error)
+}
+
+type MemoryThrottleStore struct {
+	mu      sync.Mutex
+	entries map[string]model.LoginThrottle
+}
+
+func NewMemoryThrottleStore() *MemoryThrottleStore {
+	return &MemoryThrottleStore{entries: make(map[string]model.LoginThrottle)}
+}
+
+func (s *MemoryThrottleStore) Get(key string) (model.LoginThrottle, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok, nil
+}
+
+func (s *MemoryThrottleStore) Increment(key string, now time.Time) (model.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	entry.Key = key
+	entry.Failures++
+	entry.LastFailureAt = now
+	s.entries[key] = entry
+	return entry, nil
+}
+
+func (s *MemoryThrottleStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		entry.LockedUntil = until
+		s.entries[key] = entry
+	}
+	return nil
+}
+
+func (s *MemoryThrottleStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryThrottleStore) List() ([]model.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]model.LoginThrottle, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastFailureAt.After(entries[j].LastFailureAt)
+	})
+	return entries, nil
+}
+
+func (s *MemoryThrottleStore) DeleteStale(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for key, entry := range s.entries {
+		if isStale(entry, now) {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type PostgresThrottleStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresThrottleStore(db *gorm.DB) *PostgresThrottleStore {
+	return &PostgresThrottleStore{db: db}
+}
+
+func (s *PostgresThrottleStore) Get(key string) (model.LoginThrottle, bool, error) {
+	var entry model.LoginThrottle
+	err := s.db.First(&entry, "key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entry, false, nil
+	}
+	return entry, err == nil, err
+}
+
+func (s *PostgresThrottleStore) Increment(key string, now time.Time) (model.LoginThrottle, error) {
+	entry := model.LoginThrottle{Key: key, Failures: 1, LastFailureAt: now}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("login_throttles.failures + 1"),
+			"last_failure_at": now,
+		}),
+	}).Create(&entry).Error; err != nil {
+		return entry, err
+	}
+
+	err := s.db.First(&entry, "key = ?", key).Error
+	return entry, err
+}
+
+func (s *PostgresThrottleStore) Lock(key string, until time.Time) error {
+	return s.db.Model(&model.LoginThrottle{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+func (s *PostgresThrottleStore) Delete(key string) error {
+	return s.db.Where("key = ?", key).Delete(&model.LoginThrottle{}).Error
+}
+
+func (s *PostgresThrottleStore) List() ([]model.LoginThrottle, error) {
+	var entries []model.LoginThrottle
+	err := s.db.Order("last_failure_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+func (s *PostgresThrottleStore) DeleteStale(now time.Time) (int64, error) {
+	result := s.db.
+		Where("last_failure_at < ? AND locked_until < ?", now.Add(-time.Duration(THROTTLE_RESET_AFTER)*time.Minute), now).
+		Delete(&model.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
+
+var throttleStore ThrottleStore
+var throttleStoreOnce sync.Once
+var THROTTLE_FREE_ATTEMPTS int
+var THROTTLE_BASE_DELAY int
+var THROTTLE_MAX_DELAY int
+var THROTTLE_RESET_AFTER int
+
+func LoadThrottleEnv() {
+	THROTTLE_FREE_ATTEMPTS, _ = strconv.Atoi(os.Getenv("THROTTLE_FREE_ATTEMPTS"))
+	THROTTLE_BASE_DELAY, _ = strconv.Atoi(os.Getenv("THROTTLE_BASE_DELAY"))
+	THROTTLE_MAX_DELAY, _ = strconv.Atoi(os.Getenv("THROTTLE_MAX_DELAY"))
+	THROTTLE_RESET_AFTER, _ = strconv.Atoi(os.Getenv("THROTTLE_RESET_AFTER"))
+}
+
+// getThrottleStore picks the store named by THROTTLE_STORE the first time it
+// is needed, since the database connection is opened after LoadThrottleEnv.
+func getThrottleStore() ThrottleStore {
+	throttleStoreOnce.Do(func() {
+		if os.Getenv("THROTTLE_STORE") == "postgres" {
+			throttleStore = NewPostgresThrottleStore(database.Database)
+		} else {
+			throttleStore = NewMemoryThrottleStore()
+		}
+	})
+	return throttleStore
+}
+
+// SetThrottleStore overrides the store selected through THROTTLE_STORE. Call
+// it before serving requests.
+func SetThrottleStore(store ThrottleStore) {
+	throttleStoreOnce.Do(func() {})
+	throttleStore = store
+}
+
+func isStale(entry model.LoginThrottle, now time.Time) bool {
+	return now.Sub(entry.LastFailureAt) > time.Duration(THROTTLE_RESET_AFTER)*time.Minute && now.After(entry.LockedUntil)
+}
+
+// ThrottleWait returns how long the caller has to wait before any of the keys
+// may be tried again. Zero means the attempt may go ahead.
+func ThrottleWait(keys ...string) time.Duration {
+	now := time.Now()
+	var wait time.Duration
+
+	for _, key := range keys {
+		entry, ok, err := getThrottleStore().Get(key)
+		if err != nil || !ok {
+			continue
+		}
+		if remaining := entry.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait
+}
+
+// RecordThrottleFailure counts a failed attempt for the key. Once the free
+// attempts are used up, every further failure locks the key for twice as
+// long as the previous one, up to THROTTLE_MAX_DELAY seconds. The second
+// return value reports whether this failure started a lockout.
+func RecordThrottleFailure(key string) (model.LoginThrottle, bool, error) {
+	store := getThrottleStore()
+	now := time.Now()
+
+	if entry, ok, err := store.Get(key); err != nil {
+		return entry, false, err
+	} else if ok && isStale(entry, now) {
+		if err := store.Delete(key); err != nil {
+			return entry, false, err
+		}
+	}
+
+	entry, err := store.Increment(key, now)
+	if err != nil {
+		return entry, false, err
+	}
+
+	excess := entry.Failures - THROTTLE_FREE_ATTEMPTS
+	if excess <= 0 {
+		return entry, false, nil
+	}
+
+	delay := float64(THROTTLE_BASE_DELAY) * math.Pow(2, float64(excess-1))
+	if delay > float64(THROTTLE_MAX_DELAY) {
+		delay = float64(THROTTLE_MAX_DELAY)
+	}
+
+	entry.LockedUntil = now.Add(time.Duration(delay) * time.Second)
+	if err := store.Lock(key, entry.LockedUntil); err != nil {
+		return entry, false, err
+	}
+
+	return entry, excess == 1, nil
+}
+
+func ClearThrottle(key string) error {
+	return getThrottleStore().Delete(key)
+}
+
+// ListThrottles returns every key with recent failures, dropping those that
+// have gone quiet.
+func ListThrottles() ([]model.LoginThrottle, error) {
+	entries, err := getThrottleStore().List()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]model.LoginThrottle, 0, len(entries))
+	for _, entry := range entries {
+		if !isStale(entry, now) {
+			active = append(active, entry)
+		}
+	}
+
+	return active, nil
+}
+
+// StartThrottleSweep periodically forgets keys that went quiet, so the
+// in-memory store does not grow without bound. It blocks, so run it in its
+// own goroutine.
+func StartThrottleSweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := getThrottleStore().DeleteStale(time.Now()); err != nil {
+			log.Printf("Error deleting stale throttles: %v", err)
+		}
+	}
+}
+
+func SendLockoutEmail(user model.User) {
+	body := "We noticed repeated failed sign-in attempts on your account, so sign-in has been temporarily locked. " +
+		"If this was not you, consider changing your password: " + os.Getenv("FRONTEND_URL") + "/forgot-password"
+
+	if err := SendEmail(user.Email, "Your account has been temporarily locked", body); err != nil {
+		log.Printf("Error sending lockout email: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"time"
+)
+
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// IssueUserToken creates a new single-use token for the given purpose and
+// invalidates any token previously issued to the user for the same purpose.
+func IssueUserToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	return IssueBoundUserToken(userID, purpose, ttl, "")
+}
+
+// IssueBoundUserToken is IssueUserToken for a token that can only be
+// consumed together with nonce.
+func IssueBoundUserToken(userID uint, purpose string, ttl time.Duration, nonce string) (string, error) {
+	if err := InvalidateUserTokens(userID, purpose); err != nil {
+		return "", err
+	}
+
+	rawToken := utils.GenerateSecureToken(32)
+	token := model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if nonce != "" {
+		token.NonceHash = utils.HashToken(nonce)
+	}
+
+	if err := database.Database.Create(&token).Error; err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// ConsumeUserToken marks the token as used and returns it. A token can only
+// be consumed once, even under concurrent requests.
+func ConsumeUserToken(rawToken, purpose string) (model.UserToken, error) {
+	return ConsumeBoundUserToken(rawToken, purpose, "")
+}
+
+// ConsumeBoundUserToken is ConsumeUserToken for tokens issued with a nonce.
+// A token with the wrong nonce is left untouched.
+func ConsumeBoundUserToken(rawToken, purpose, nonce string) (model.UserToken, error) {
+	var token model.UserToken
+	if err := database.Database.
+		Where("token_hash = ? AND purpose = ?", utils.HashToken(rawToken), purpose).
+		First(&token).Error; err != nil {
+		return token, ErrInvalidUserToken
+	}
+
+	if token.NonceHash != "" && (nonce == "" || subtle.ConstantTimeCompare([]byte(token.NonceHash), []byte(utils.HashToken(nonce))) != 1) {
+		return token, ErrInvalidUserToken
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return token, ErrInvalidUserToken
+	}
+
+	result := database.Database.Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return token, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return token, ErrInvalidUserToken
+	}
+
+	return token, nil
+}
+
+func InvalidateUserTokens(userID uint, purpose string) error {
+	return database.Database.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
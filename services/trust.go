@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"onichan/database"
+	"onichan/model"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrustRequirement lists what an account needs to reach a trust level. Bans
+// issued in the last trustBanWindow count against MaxRecentBans.
+type TrustRequirement struct {
+	AccountDays       int   `json:"account_days"`
+	ThreadsRead       int64 `json:"threads_read"`
+	Posts             int64 `json:"posts"`
+	ReactionsReceived int64 `json:"reactions_received"`
+	MaxRemovedPosts   int64 `json:"max_removed_posts"`
+	MaxRecentBans     int64 `json:"max_recent_bans"`
+}
+
+const trustBanWindow = 180 * 24 * time.Hour
+
+// defaultTrustRequirements are the requirements for levels 1 to 3. Level 4 is
+// only granted by admins.
+const defaultTrustRequirements = `[` +
+	`{"account_days":1,"threads_read":5,"posts":1,"reactions_received":0,"max_removed_posts":3,"max_recent_bans":1},` +
+	`{"account_days":15,"threads_read":30,"posts":10,"reactions_received":5,"max_removed_posts":1,"max_recent_bans":0},` +
+	`{"account_days":60,"threads_read":100,"posts":50,"reactions_received":30,"max_removed_posts":0,"max_recent_bans":0}` +
+	`]`
+
+var ErrInvalidTrustLevel = errors.New("trust level must be between 0 and 4")
+
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)\S`)
+
+func parseTrustRequirements(value string) ([]TrustRequirement, error) {
+	var requirements []TrustRequirement
+	if err := json.Unmarshal([]byte(value), &requirements); err != nil {
+		return nil, err
+	}
+	if len(requirements) >= model.TrustLevelLeader {
+		return nil, ErrInvalidSettingValue
+	}
+	return requirements, nil
+}
+
+func isTrustRequirements(value string) bool {
+	_, err := parseTrustRequirements(value)
+	return err == nil
+}
+
+func isTrustLevel(value string) bool {
+	level, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	return level >= 0 && level <= model.TrustLevelLeader
+}
+
+// MeetsTrustLevel reports whether a user with the given trust level may do
+// what the setting gates. Staff always may.
+func MeetsTrustLevel(userID uint, role string, trustLevel int, setting string) bool {
+	return trustLevel >= GetIntSetting(setting) || IsStaff(userID, role)
+}
+
+func ContainsLink(texts ...string) bool {
+	for _, text := range texts {
+		if linkPattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordThreadView counts a thread as read by the user for their trust level.
+func RecordThreadView(userID, postID uint) {
+	database.Database.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.PostView{UserID: userID, PostID: postID})
+}
+
+// ComputeTrustLevel returns the highest level whose requirements the user and
+// every lower level meet.
+func ComputeTrustLevel(user model.User) (int, error) {
+	requirements, err := parseTrustRequirements(GetSetting(SettingTrustRequirements))
+	if err != nil {
+		return user.TrustLevel, err
+	}
+
+	stats, err := GetUserStats(user)
+	if err != nil {
+		return user.TrustLevel, err
+	}
+
+	var threadsRead, removedPosts, recentBans int64
+	if err := database.Database.Model(&model.PostView{}).Where("user_id = ?", user.ID).Count(&threadsRead).Error; err != nil {
+		return user.TrustLevel, err
+	}
+	if err := database.Database.Model(&model.Post{}).Where("user_id = ? AND removed_at IS NOT NULL", user.ID).Count(&removedPosts).Error; err != nil {
+		return user.TrustLevel, err
+	}
+	if err := database.Database.Model(&model.Ban{}).Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-trustBanWindow)).Count(&recentBans).Error; err != nil {
+		return user.TrustLevel, err
+	}
+
+	accountAge := time.Since(user.CreatedAt)
+	level := model.TrustLevelNew
+	for _, requirement := range requirements {
+		if accountAge < time.Duration(requirement.AccountDays)*24*time.Hour ||
+			threadsRead < requirement.ThreadsRead ||
+			stats.PostCount < requirement.Posts ||
+			stats.ReactionsReceived < requirement.ReactionsReceived ||
+			removedPosts > requirement.MaxRemovedPosts ||
+			recentBans > requirement.MaxRecentBans {
+			break
+		}
+		level++
+	}
+
+	return level, nil
+}
+
+// UpdateTrustLevel recomputes the trust level of a user whose level is not
+// pinned by an admin.
+func UpdateTrustLevel(user model.User) error {
+	if user.TrustLevelLocked {
+		return nil
+	}
+
+	level, err := ComputeTrustLevel(user)
+	if err != nil || level == user.TrustLevel {
+		return err
+	}
+
+	return database.Database.Model(&user).Update("trust_level", level).Error
+}
+
+// UpdateAllTrustLevels recomputes the trust level of every active user.
+func UpdateAllTrustLevels() error {
+	var users []model.User
+	return database.Database.
+		Where("trust_level_locked = ? AND status = ?", false, model.UserStatusActive).
+		FindInBatches(&users, 200, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				if err := UpdateTrustLevel(user); err != nil {
+					fmt.Println(err)
+				}
+			}
+			return nil
+		}).Error
+}
+
+// StartTrustLevelUpdates recomputes trust levels every interval.
+func StartTrustLevelUpdates(interval time.Duration) {
+	for {
+		if err := UpdateAllTrustLevels(); err != nil {
+			fmt.Println("Error updating trust levels:", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// SetTrustLevel pins the user's trust level, or with a nil level lets it be
+// computed automatically again.
+func SetTrustLevel(user model.User, level *int) (model.User, error) {
+	if level == nil {
+		user.TrustLevelLocked = false
+		if err := database.Database.Model(&user).Update("trust_level_locked", false).Error; err != nil {
+			return user, err
+		}
+		if err := UpdateTrustLevel(user); err != nil {
+			return user, err
+		}
+		err := database.Database.First(&user, user.ID).Error
+		return user, err
+	}
+
+	if *level < 0 || *level > model.TrustLevelLeader {
+		return user, ErrInvalidTrustLevel
+	}
+
+	user.TrustLevel = *level
+	user.TrustLevelLocked = true
+	err := database.Database.Model(&user).Updates(map[string]interface{}{
+		"trust_level":        *level,
+		"trust_level_locked": true,
+	}).Error
+	return user, err
+}
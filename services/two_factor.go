@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/utils"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// IsTwoFactorEnabled reports whether the user confirmed a TOTP secret. Callers
+// must treat an error as a failed check rather than as no second factor.
+func IsTwoFactorEnabled(userID uint) (bool, error) {
+	var count int64
+	err := database.Database.Model(&model.TwoFactor{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL", userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// StartTwoFactorEnrollment generates a new, unconfirmed TOTP secret for the
+// user, replacing any previous unconfirmed one. It returns the secret and the
+// otpauth:// URI for authenticator apps.
+func StartTwoFactorEnrollment(user model.User) (string, string, error) {
+	enabled, err := IsTwoFactorEnabled(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret := utils.GenerateTOTPSecret()
+
+	err = database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.TwoFactor{UserID: user.ID, Secret: secret}).Error
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "onichan"
+	}
+
+	return secret, utils.TOTPURI(issuer, user.Username, secret), nil
+}
+
+// ConfirmTwoFactor activates a pending enrollment once the user proves their
+// authenticator works, and returns a fresh set of recovery codes.
+func ConfirmTwoFactor(userID uint, code string) ([]string, error) {
+	var twoFactor model.TwoFactor
+	if err := database.Database.Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	if twoFactor.ConfirmedAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	if err := database.Database.Model(&twoFactor).Updates(map[string]interface{}{
+		"confirmed_at":   time.Now(),
+		"last_used_step": step,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return GenerateRecoveryCodes(userID)
+}
+
+func DisableTwoFactor(userID uint) error {
+	return database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.TwoFactor{}).Error
+	})
+}
+
+// GenerateRecoveryCodes replaces the user's recovery codes. The plain codes
+// are only returned here; the database keeps their hashes.
+func GenerateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]model.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code := strings.ToLower(utils.GenerateTOTPSecret()[:10])
+		codes[i] = code[:5] + "-" + code[5:]
+		records[i] = model.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(codes[i])}
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifySecondFactor accepts either a current TOTP code or an unused recovery
+// code. A TOTP code is never accepted twice, and a recovery code is burnt on
+// use.
+func VerifySecondFactor(userID uint, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		result := database.Database.Model(&model.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(strings.ToLower(strings.TrimSpace(recoveryCode)))).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	var twoFactor model.TwoFactor
+	if err := database.Database.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&twoFactor).Error; err != nil {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	result := database.Database.Model(&model.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", twoFactor.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"onichan/database"
+	"onichan/model"
+	"onichan/websocket"
+	"strings"
+	"unicode/utf8"
+)
+
+// GetUserStats counts the user's visible posts, the threads they started, the
+// reactions their posts received, their followers and the users they follow.
+func GetUserStats(user model.User) (model.UserStats, error) {
+	stats := model.UserStats{JoinedAt: user.CreatedAt}
+
+	if err := database.Database.Model(&model.Post{}).
+		Where("user_id = ? AND is_deleted = ?", user.ID, false).
+		Count(&stats.PostCount).Error; err != nil {
+		return stats, err
+	}
+
+	if err := database.Database.Model(&model.Post{}).
+		Where("user_id = ? AND is_deleted = ? AND is_master_post = ?", user.ID, false, true).
+		Count(&stats.ThreadsStarted).Error; err != nil {
+		return stats, err
+	}
+
+	if err := database.Database.Model(&model.PostReaction{}).
+		Joins("JOIN posts ON posts.id = post_reactions.post_id").
+		Where("posts.user_id = ? AND posts.deleted_at IS NULL", user.ID).
+		Count(&stats.ReactionsReceived).Error; err != nil {
+		return stats, err
+	}
+
+	if err := database.Database.Model(&model.Follow{}).
+		Where("following_id = ?", user.ID).
+		Count(&stats.Followers).Error; err != nil {
+		return stats, err
+	}
+
+	err := database.Database.Model(&model.Follow{}).
+		Where("follower_id = ? AND following_id IS NOT NULL", user.ID).
+		Count(&stats.Following).Error
+
+	return stats, err
+}
+
+var (
+	ErrInvalidUsername = errors.New("username must be between 1 and 31 characters")
+	ErrUsernameTaken   = errors.New("username is already taken")
+)
+
+// ChangeUsername renames the user.
+func ChangeUsername(user model.User, username string) error {
+	username = strings.TrimSpace(username)
+	if username == "" || utf8.RuneCountInString(username) > 31 {
+		return ErrInvalidUsername
+	}
+
+	var count int64
+	if err := database.Database.Model(&model.User{}).Where("username = ? AND id <> ?", username, user.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUsernameTaken
+	}
+
+	return database.Database.Model(&user).Update("username", username).Error
+}
+
+// RevokeAllSessions signs the user out everywhere and revokes their API
+// tokens.
+func RevokeAllSessions(user model.User) error {
+	if err := RevokeUserSessions(user.ID, 0); err != nil {
+		return err
+	}
+	if err := RevokeUserAPITokens(user.ID); err != nil {
+		return err
+	}
+
+	websocket.DisconnectUser(user.ID)
+	return nil
+}
+
+// ForcePasswordReset clears the user's password, signs them out and emails
+// them a password reset link. Until they set a new password they can only
+// sign in through linked identities or magic links.
+func ForcePasswordReset(user model.User) error {
+	if err := database.Database.Model(&user).Updates(map[string]interface{}{
+		"password_hash": "",
+		"salt":          "",
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := RevokeAllSessions(user); err != nil {
+		return err
+	}
+
+	return SendPasswordResetEmail(user)
+}
+
+// GetModerationHistory returns the user's bans, removed posts, reports on
+// their posts and messages, and the admin actions taken on their account,
+// newest first.
+func GetModerationHistory(userID uint) (model.ModerationHistory, error) {
+	var history model.ModerationHistory
+
+	if err := database.Database.
+		Preload("IssuedBy").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&history.Bans).Error; err != nil {
+		return history, err
+	}
+
+	if err := database.Database.
+		Preload("Category").
+		Where("user_id = ? AND removed_at IS NOT NULL", userID).
+		Order("removed_at DESC").
+		Find(&history.RemovedPosts).Error; err != nil {
+		return history, err
+	}
+
+	if err := database.Database.
+		Preload("User").
+		Preload("Post").
+		Preload("Message").
+		Where("post_id IN (?) OR message_id IN (?)",
+			database.Database.Model(&model.Post{}).Select("id").Where("user_id = ?", userID),
+			database.Database.Unscoped().Model(&model.Message{}).Select("id").Where("user_id = ?", userID)).
+		Order("created_at DESC").
+		Find(&history.Reports).Error; err != nil {
+		return history, err
+	}
+
+	err := database.Database.
+		Preload("Actor").
+		Where("target_user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&history.AuditLogs).Error
+
+	return history, err
+}
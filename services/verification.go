@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"onichan/database"
+	"onichan/model"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var EMAIL_VERIFICATION_TTL int
+var UNVERIFIED_ACCOUNT_TTL int
+
+func LoadVerificationEnv() {
+	EMAIL_VERIFICATION_TTL, _ = strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL"))
+	UNVERIFIED_ACCOUNT_TTL, _ = strconv.Atoi(os.Getenv("UNVERIFIED_ACCOUNT_TTL"))
+}
+
+// VerificationResendInterval is how long a user waits before they can ask
+// for another verification email.
+const VerificationResendInterval = 5 * time.Minute
+
+var ErrVerificationResendTooSoon = errors.New("a verification email was sent recently, please wait a few minutes")
+
+func SendVerificationEmail(user model.User) error {
+	token, err := IssueUserToken(user.ID, model.TokenPurposeEmailVerification, time.Duration(EMAIL_VERIFICATION_TTL)*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	return SendEmail(user.Email, "Verify your email", "Click the link to verify your email address: "+os.Getenv("FRONTEND_URL")+"/verify-email?token="+token)
+}
+
+// ResendVerificationEmail sends a new verification link unless the user got
+// one within VerificationResendInterval.
+func ResendVerificationEmail(user model.User) error {
+	if !debounceEmail(user.ID, "verification", VerificationResendInterval) {
+		return ErrVerificationResendTooSoon
+	}
+	return SendVerificationEmail(user)
+}
+
+// VerifyEmail consumes a verification token and marks the owner's email as
+// verified.
+func VerifyEmail(rawToken string) (model.User, error) {
+	var user model.User
+
+	token, err := ConsumeUserToken(rawToken, model.TokenPurposeEmailVerification)
+	if err != nil {
+		return user, err
+	}
+
+	if err := database.Database.First(&user, token.UserID).Error; err != nil {
+		return user, ErrInvalidUserToken
+	}
+
+	if err := MarkEmailVerified(&user); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// MarkEmailVerified records that the user proved they own their email, which
+// also exempts the account from DeleteExpiredUnverifiedUsers for good.
+func MarkEmailVerified(user *model.User) error {
+	now := time.Now()
+	if err := database.Database.Model(user).Updates(map[string]interface{}{
+		"email_verified_at": now,
+		"unverified_since":  nil,
+	}).Error; err != nil {
+		return err
+	}
+
+	user.EmailVerifiedAt = &now
+	user.UnverifiedSince = nil
+	return nil
+}
+
+// DeleteExpiredUnverifiedUsers removes active accounts that never verified
+// their email within UNVERIFIED_ACCOUNT_TTL hours of signing up, releasing
+// their username and email. Accounts that already authored posts are kept, as
+// are established accounts that only changed their email.
+func DeleteExpiredUnverifiedUsers() (int64, error) {
+	if UNVERIFIED_ACCOUNT_TTL <= 0 {
+		return 0, nil
+	}
+
+	var userIDs []uint
+	if err := database.Database.Model(&model.User{}).
+		Where("email_verified_at IS NULL AND unverified_since < ?", time.Now().Add(-time.Duration(UNVERIFIED_ACCOUNT_TTL)*time.Hour)).
+		Where("status NOT IN ?", []string{model.UserStatusDeleted, model.UserStatusPending}).
+		Where("NOT EXISTS (SELECT 1 FROM posts WHERE posts.user_id = users.id)").
+		Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	err := database.Database.Transaction(func(tx *gorm.DB) error {
+		return deleteUsers(tx, userIDs)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		removeAvatarUploads(userID, "")
+	}
+
+	return int64(len(userIDs)), nil
+}
+
+// purgeCredentials removes everything the users could sign in with: sessions,
+// tokens, linked identities and two-factor secrets.
+func purgeCredentials(tx *gorm.DB, userIDs []uint) error {
+	if err := tx.Unscoped().Where("session_id IN (?)", tx.Model(&model.Session{}).Select("id").Where("user_id IN ?", userIDs)).Delete(&model.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.Session{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.UserToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.APIToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.Identity{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.TwoFactor{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.RecoveryCode{}).Error
+}
+
+// deleteUsers hard-deletes accounts that never took part in the forum,
+// together with everything that references them. Callers remove the users'
+// avatar uploads once the transaction committed.
+func deleteUsers(tx *gorm.DB, userIDs []uint) error {
+	if err := purgeCredentials(tx, userIDs); err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.RoleAssignment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.Ban{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("follower_id IN ? OR following_id IN ?", userIDs, userIDs).Delete(&model.Follow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ? OR target_id IN ?", userIDs, userIDs).Delete(&model.Block{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id IN ?", userIDs).Delete(&model.PostView{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id IN ?", userIDs).Delete(&model.UserPreferences{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id IN ?", userIDs).Delete(&model.EmailDebounce{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.PostReaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ? OR message_id IN (?)", userIDs, tx.Unscoped().Model(&model.Message{}).Select("id").Where("user_id IN ?", userIDs)).Delete(&model.Report{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(&model.Message{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id IN ?", userIDs).Delete(&model.ConversationParticipant{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id IN ? OR from_user_id IN ?", userIDs, userIDs).Delete(&model.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.AuditLog{}).Where("actor_id IN ?", userIDs).Update("actor_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.AuditLog{}).Where("target_user_id IN ?", userIDs).Update("target_user_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&model.User{}, userIDs).Error
+}
+
+// StartUnverifiedUserCleanup periodically runs DeleteExpiredUnverifiedUsers.
+// It blocks, so run it in its own goroutine.
+func StartUnverifiedUserCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := DeleteExpiredUnverifiedUsers()
+		if err != nil {
+			log.Printf("Error deleting unverified users: %v", err)
+		} else if count > 0 {
+			log.Printf("Deleted %d unverified users", count)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Cookie session mode keeps the access and refresh tokens in HttpOnly
+// cookies. The CSRF token is readable by JavaScript and has to be echoed in
+// the X-CSRF-Token header on state-changing requests.
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+
+	// RefreshCookiePath limits the refresh cookie to the endpoints that use
+	// it: refresh and logout.
+	RefreshCookiePath = "/api/auth"
+)
+
+var cookieDomain string
+var cookieSecure bool
+var cookieSameSite http.SameSite
+var allowedOrigins []string
+
+// LoadCookieEnv reads COOKIE_DOMAIN, COOKIE_SECURE, COOKIE_SAMESITE (lax,
+// strict or none) and the comma separated CORS_ALLOWED_ORIGINS.
+func LoadCookieEnv() {
+	cookieDomain = os.Getenv("COOKIE_DOMAIN")
+
+	secure, err := strconv.ParseBool(os.Getenv("COOKIE_SECURE"))
+	cookieSecure = err != nil || secure
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "strict":
+		cookieSameSite = http.SameSiteStrictMode
+	case "none":
+		cookieSameSite = http.SameSiteNoneMode
+	default:
+		cookieSameSite = http.SameSiteLaxMode
+	}
+
+	allowedOrigins = nil
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowedOrigins = append(allowedOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+}
+
+// NewCookie builds a cookie with the configured domain, Secure and SameSite
+// attributes. A negative maxAge deletes the cookie.
+func NewCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cookieDomain,
+		MaxAge:   maxAge,
+		Secure:   cookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: cookieSameSite,
+	}
+}
+
+// AllowedOrigins returns the origins allowed to make credentialed requests.
+func AllowedOrigins() []string {
+	return allowedOrigins
+}
+
+func IsAllowedOrigin(origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
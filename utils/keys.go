@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	crand "crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Signing keys live in JWT_KEYS_DIR as PKCS#8 PEM files named <kid>.pem. Every
+// key in the directory is accepted for verification and published in the
+// JWKS, and the newest one signs new tokens once it has been published for
+// JWT_KEY_ACTIVATION_DELAY seconds, so that other instances have picked it up
+// before tokens signed with it reach them.
+
+const keyIDTimeFormat = "20060102T150405Z"
+
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+var ErrUnknownKeyID = errors.New("unknown JWT key id")
+var ErrUnsupportedKeyAlgorithm = errors.New("unsupported key algorithm, use RS256 or EdDSA")
+
+type signingKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+var (
+	keysMutex     sync.RWMutex
+	signingKeys   []signingKey
+	keysDir       string
+	keyActivation time.Duration
+)
+
+func loadKeyEnv() {
+	keysDir = os.Getenv("JWT_KEYS_DIR")
+	if keysDir == "" {
+		keysDir = "keys"
+	}
+
+	activationDelay, _ := time.ParseDuration(os.Getenv("JWT_KEY_ACTIVATION_DELAY") + "s")
+	keyActivation = activationDelay
+}
+
+// LoadSigningKeys reads every key from JWT_KEYS_DIR, replacing the keys
+// loaded before.
+func LoadSigningKeys() error {
+	keys, err := readSigningKeys(keysDir)
+	if err != nil {
+		return err
+	}
+
+	keysMutex.Lock()
+	signingKeys = keys
+	keysMutex.Unlock()
+
+	return nil
+}
+
+// StartKeyReload periodically reloads the signing keys so that keys added or
+// removed by another instance are picked up without a restart. It blocks, so
+// run it in its own goroutine.
+func StartKeyReload(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := LoadSigningKeys(); err != nil {
+			log.Printf("Error reloading JWT signing keys: %v", err)
+		}
+	}
+}
+
+func readSigningKeys(dir string) ([]signingKey, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys []signingKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		key, err := readSigningKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	// Newest first.
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func readSigningKey(path string) (signingKey, error) {
+	var key signingKey
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return key, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return key, errors.New(path + ": no PEM data found")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return key, errors.New(path + ": " + err.Error())
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Private = private
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = private
+	default:
+		return key, errors.New(path + ": " + ErrUnsupportedKeyAlgorithm.Error())
+	}
+
+	key.ID = strings.TrimSuffix(filepath.Base(path), ".pem")
+
+	// Key ids generated by GenerateSigningKey start with their creation time.
+	// Fall back to the file time for keys added by hand.
+	createdAt, err := time.Parse(keyIDTimeFormat, strings.SplitN(key.ID, "-", 2)[0])
+	if err != nil {
+		info, err := os.Stat(path)
+		if err != nil {
+			return key, err
+		}
+		createdAt = info.ModTime()
+	}
+	key.CreatedAt = createdAt
+
+	return key, nil
+}
+
+// activeSigningKey returns the newest key that has been published for long
+// enough, or the oldest one when none has.
+func activeSigningKey() (signingKey, bool) {
+	keysMutex.RLock()
+	defer keysMutex.RUnlock()
+
+	if len(signingKeys) == 0 {
+		return signingKey{}, false
+	}
+
+	for _, key := range signingKeys {
+		if time.Since(key.CreatedAt) >= keyActivation {
+			return key, true
+		}
+	}
+
+	return signingKeys[len(signingKeys)-1], true
+}
+
+func findSigningKey(kid string) (signingKey, bool) {
+	keysMutex.RLock()
+	defer keysMutex.RUnlock()
+
+	for _, key := range signingKeys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+
+	return signingKey{}, false
+}
+
+// signToken signs the claims with the active key, or with JWT_SECRET_KEY
+// using HS256 on deployments that have not set up signing keys yet.
+func signToken(claims jwt.MapClaims) (string, error) {
+	if jwtIssuer != "" {
+		claims["iss"] = jwtIssuer
+	}
+	claims["iat"] = time.Now().Unix()
+
+	key, ok := activeSigningKey()
+	if !ok {
+		if len(jwtSecret) == 0 {
+			return "", ErrNoSigningKey
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// verificationKey is the jwt.Keyfunc for our own tokens. HS256 tokens are
+// still accepted while JWT_SECRET_KEY is set, so that switching to signing
+// keys does not log everyone out.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && len(jwtSecret) > 0 {
+			return jwtSecret, nil
+		}
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	key, ok := findSigningKey(kid)
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return key.Private.Public(), nil
+}
+
+// PublicJWKS returns the public half of every loaded signing key.
+func PublicJWKS() JWKS {
+	keysMutex.RLock()
+	defer keysMutex.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range signingKeys {
+		jwk, err := NewJWK(key.ID, key.Method.Alg(), key.Private.Public())
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// GenerateSigningKey creates a new key for alg (RS256 or EdDSA) in dir and
+// returns its key id.
+func GenerateSigningKey(dir, alg string) (string, error) {
+	var private crypto.Signer
+	var err error
+
+	switch alg {
+	case "RS256":
+		private, err = rsa.GenerateKey(crand.Reader, 2048)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(crand.Reader)
+	default:
+		return "", ErrUnsupportedKeyAlgorithm
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	kid := time.Now().UTC().Format(keyIDTimeFormat) + "-" + strings.ToLower(GetToken(6))
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		return "", err
+	}
+
+	return kid, nil
+}
+
+// PruneSigningKeys deletes all but the keep newest keys in dir and returns
+// the ids of the deleted keys.
+func PruneSigningKeys(dir string, keep int) ([]string, error) {
+	keys, err := readSigningKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := keep; i < len(keys); i++ {
+		if err := os.Remove(filepath.Join(dir, keys[i].ID+".pem")); err != nil {
+			return removed, err
+		}
+		removed = append(removed, keys[i].ID)
+	}
+
+	return removed, nil
+}
+
+// KeysDir is the directory signing keys are loaded from.
+func KeysDir() string {
+	if keysDir == "" {
+		loadKeyEnv()
+	}
+	return keysDir
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	crand "crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Passwords are stored as PHC strings:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+//
+// Accounts created before Argon2id still hold a bcrypt hash of salt+password
+// with the salt in its own column. Those keep working and are rehashed the
+// next time the password is checked.
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var argon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// LoadPasswordHashing reads the Argon2id cost from ARGON2_MEMORY (KiB),
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM, keeping the defaults for unset
+// values.
+func LoadPasswordHashing() {
+	if memory, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32); err == nil && memory > 0 {
+		argon2Params.Memory = uint32(memory)
+	}
+	if iterations, err := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); err == nil && iterations > 0 {
+		argon2Params.Iterations = uint32(iterations)
+	}
+	if parallelism, err := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); err == nil && parallelism > 0 {
+		argon2Params.Parallelism = uint8(parallelism)
+	}
+}
+
+func HashPassword(password string) (string, error) {
+	return hashPasswordWith(password, argon2Params)
+}
+
+func hashPasswordWith(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := crand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword checks password against a stored hash. legacySalt is only
+// used for bcrypt hashes. needsRehash reports whether a matching hash should
+// be replaced because it uses an old format or different parameters.
+func VerifyPassword(encodedHash, legacySalt, password string) (match bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(encodedHash)
+		if err != nil {
+			return false, false, err
+		}
+
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, computed) != 1 {
+			return false, false, nil
+		}
+
+		return true, params != argon2Params, nil
+	case strings.HasPrefix(encodedHash, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(legacySalt+password)); err != nil {
+			return false, false, nil
+		}
+
+		return true, true, nil
+	case encodedHash == "":
+		// Accounts created through an external provider have no password.
+		return false, false, nil
+	default:
+		return false, false, ErrInvalidPasswordHash
+	}
+}
+
+func decodeArgon2Hash(encodedHash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// CalibrateArgon2 finds the largest iteration count that hashes a password
+// within target using the given memory and parallelism, halving the memory
+// when even a single iteration is too slow. It returns the parameters and the
+// time they took.
+func CalibrateArgon2(target time.Duration, memory uint32, parallelism uint8) (Argon2Params, time.Duration) {
+	params := argon2Params
+	params.Memory = memory
+	params.Parallelism = parallelism
+	params.Iterations = 1
+
+	measure := func(p Argon2Params) time.Duration {
+		start := time.Now()
+		hashPasswordWith("calibration password", p)
+		return time.Since(start)
+	}
+
+	elapsed := measure(params)
+	for elapsed > target && params.Memory > 8*1024 {
+		params.Memory /= 2
+		elapsed = measure(params)
+	}
+
+	for {
+		next := params
+		next.Iterations++
+		nextElapsed := measure(next)
+		if nextElapsed > target {
+			break
+		}
+		params, elapsed = next, nextElapsed
+	}
+
+	return params, elapsed
+}
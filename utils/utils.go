@@ -1,7 +1,11 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
 	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"log"
 	"onichan/database"
 	"onichan/model"
@@ -15,8 +19,19 @@ import (
 )
 
 var jwtSecret []byte
+var jwtIssuer string
 var jwtTTL int
 
+// Token audiences. A token is only accepted where its audience is expected, so
+// a two-factor challenge or websocket token can never be used as an access
+// token and the other way around.
+const (
+	AudienceAccess            = "access"
+	AudienceWebSocket         = "websocket"
+	AudienceTwoFactor         = "2fa_pending"
+	AudienceOAuthRegistration = "oauth_registration"
+)
+
 func LoadEnv() {
 	err := godotenv.Load(".env")
 
@@ -25,45 +40,104 @@ func LoadEnv() {
 	}
 }
 
+// LoadJWT loads the token settings and signing keys. JWT_SECRET_KEY is only
+// used for HS256 tokens on deployments without signing keys, and to keep
+// accepting their tokens while switching over.
 func LoadJWT() {
 	jwtSecret = []byte(os.Getenv("JWT_SECRET_KEY"))
-	jwtTTL, _ = strconv.Atoi(os.Getenv("TOKEN_TTL"))
+	jwtIssuer = os.Getenv("JWT_ISSUER")
+	jwtTTL, _ = strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL"))
+	loadKeyEnv()
+
+	if err := LoadSigningKeys(); err != nil {
+		log.Fatalf("Error loading JWT signing keys: %v", err)
+	}
+	if _, ok := activeSigningKey(); !ok && len(jwtSecret) == 0 {
+		log.Fatalf("Error loading JWT signing keys: %v", ErrNoSigningKey)
+	}
+}
+
+// AccessTokenTTL is how long an access token issued by GenerateJWT stays valid.
+func AccessTokenTTL() time.Duration {
+	return time.Duration(jwtTTL) * time.Minute
 }
 
-func GenerateJWT(userID uint) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Duration(jwtTTL) * time.Hour).Unix(),
+func GenerateJWT(userID uint, sessionID uint) (string, error) {
+	return signToken(jwt.MapClaims{
+		"aud":        AudienceAccess,
+		"user_id":    userID,
+		"session_id": sessionID,
+		"exp":        time.Now().Add(AccessTokenTTL()).Unix(),
+	})
+}
+
+// ValidateJWT checks the signature, expiry, issuer and audience of a token.
+func ValidateJWT(tokenString, audience string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, verificationKey)
+	if err != nil {
+		return token, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	claims := token.Claims.(jwt.MapClaims)
+	if !claims.VerifyAudience(audience, true) {
+		return token, jwt.ErrSignatureInvalid
+	}
+	if jwtIssuer != "" && !claims.VerifyIssuer(jwtIssuer, true) {
+		return token, jwt.ErrSignatureInvalid
+	}
+
+	return token, nil
+}
 
-	return token.SignedString(jwtSecret)
+// GenerateWebSocketToken issues the short-lived token used to open the
+// websocket, so that access tokens never end up in URLs.
+func GenerateWebSocketToken(userID uint, sessionID uint) (string, error) {
+	return signToken(jwt.MapClaims{
+		"aud":        AudienceWebSocket,
+		"user_id":    userID,
+		"session_id": sessionID,
+		"exp":        time.Now().Add(time.Minute).Unix(),
+	})
 }
 
-func GenerateJWTForgotPassword(userID uint, passwordHash string) (string, error) {
-	exp, _ := strconv.Atoi(os.Getenv("EMAIL_EXPIRATION"))
+// GeneratePurposeToken issues a short-lived JWT for a single purpose, such
+// as a pending two-factor login. The purpose is the token's audience, and
+// purpose tokens carry no session, so they are never accepted as access
+// tokens.
+func GeneratePurposeToken(purpose string, claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	claims["aud"] = purpose
+	claims["exp"] = time.Now().Add(ttl).Unix()
+
+	return signToken(claims)
+}
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Duration(exp) * time.Second).Unix(),
-		"hash":    passwordHash,
+func ValidatePurposeToken(tokenString, purpose string) (jwt.MapClaims, error) {
+	token, err := ValidateJWT(tokenString, purpose)
+	if err != nil || !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.Claims.(jwt.MapClaims), nil
+}
 
-	return token.SignedString(jwtSecret)
+// GenerateTwoFactorChallenge issues the token handed out by Login when the
+// account has two-factor authentication enabled.
+func GenerateTwoFactorChallenge(userID uint) (string, error) {
+	return GeneratePurposeToken(AudienceTwoFactor, jwt.MapClaims{"user_id": userID}, 5*time.Minute)
 }
 
-func ValidateJWT(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
+func ValidateTwoFactorChallenge(tokenString string) (uint, error) {
+	claims, err := ValidatePurposeToken(tokenString, AudienceTwoFactor)
+	if err != nil {
+		return 0, err
+	}
 
-	return token, err
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, jwt.ErrSignatureInvalid
+	}
+
+	return uint(userID), nil
 }
 
 func GetRandomAvatar() string {
@@ -78,9 +152,17 @@ func GetRandomAvatar() string {
 	return avatar.AvatarURL
 }
 
-func GetPostPage(post model.Post) int {
+// UploadPath is the directory uploaded files are stored in and served from.
+func UploadPath() string {
+	if path := os.Getenv("UPLOAD_PATH"); path != "" {
+		return path
+	}
+	return "uploads"
+}
+
+// GetPostPage returns the page of its thread the reply is on.
+func GetPostPage(post model.Post, pageSize int) int {
 	var count int64
-	pageSize, _ := strconv.Atoi(os.Getenv("PAGE_SIZE"))
 	database.Database.Model(&model.Post{}).Where("created_at < ? AND parent_post_id = ?", post.CreatedAt, post.ParentPostID).Count(&count)
 	return int((int(count) + pageSize + 1) / pageSize)
 }
@@ -94,11 +176,19 @@ func GetToken(length int) string {
 	return base32.StdEncoding.EncodeToString(randomBytes)[:length]
 }
 
-func GetRandomAlphaString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+// GenerateSecureToken returns a URL-safe random string built from n bytes of
+// crypto/rand output. Use it for anything that acts as a credential.
+func GenerateSecureToken(n int) string {
+	randomBytes := make([]byte, n)
+	if _, err := crand.Read(randomBytes); err != nil {
+		panic(err)
 	}
-	return string(b)
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
+
+// HashToken returns the hex encoded SHA-256 digest of token, which is what we
+// store in place of opaque credentials such as refresh tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
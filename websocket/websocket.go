@@ -6,12 +6,10 @@ import (
 	"log"
 	"net/http"
 	"onichan/model"
-	"onichan/utils"
 	"strconv"
 	"sync"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/websocket"
 )
 
@@ -20,6 +18,10 @@ type MessagePayload struct {
 	Data string `json:"data"`
 }
 
+// maxMessageSize caps inbound frames in bytes. Clients only send small
+// subscription payloads; larger frames close the connection.
+const maxMessageSize = 512
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -32,6 +34,16 @@ var upgrader = websocket.Upgrader{
 type Client struct {
 	Conn   *websocket.Conn
 	PostID uint
+	// writeMu serializes writes, since a connection supports only one
+	// concurrent writer.
+	writeMu *sync.Mutex
+}
+
+// WriteJSON sends v to the client, waiting for any other write to finish.
+func (c Client) WriteJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Conn.WriteJSON(v)
 }
 
 var Users = make(map[uint]Client)
@@ -39,20 +51,7 @@ var Posts = make(map[uint]map[uint]bool)
 var mu sync.Mutex
 
 func WsHandler(c *gin.Context) {
-	token := c.Query("token")
-	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
-		return
-	}
-
-	jwtToken, err := utils.ValidateJWT(token)
-	if err != nil || !jwtToken.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		return
-	}
-
-	claims := jwtToken.Claims.(jwt.MapClaims)
-	userID := uint(claims["user_id"].(float64))
+	userID := uint(c.MustGet("user_id").(float64))
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -60,9 +59,10 @@ func WsHandler(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	conn.SetReadLimit(maxMessageSize)
 
 	mu.Lock()
-	client := Client{Conn: conn}
+	client := Client{Conn: conn, writeMu: &sync.Mutex{}}
 	Users[userID] = client
 	mu.Unlock()
 
@@ -81,6 +81,7 @@ func WsHandler(c *gin.Context) {
 
 		if payload.Type == "post" {
 			postID, _ := strconv.Atoi(payload.Data)
+			mu.Lock()
 			if Posts[uint(postID)] == nil {
 				Posts[uint(postID)] = make(map[uint]bool)
 			}
@@ -91,11 +92,16 @@ func WsHandler(c *gin.Context) {
 			client := Users[userID]
 			client.PostID = uint(postID)
 			Users[userID] = client
+			mu.Unlock()
 		}
 	}
 
+	// A reconnect replaces the entry, so only remove it while it is still
+	// this connection.
 	mu.Lock()
-	delete(Users, userID)
+	if Users[userID].Conn == conn {
+		delete(Users, userID)
+	}
 	mu.Unlock()
 }
 
@@ -106,7 +112,7 @@ func SendWebSocketNotification(userID uint, message model.Notification) {
 	if !ok {
 		return
 	} else {
-		if err := client.Conn.WriteJSON(gin.H{
+		if err := client.WriteJSON(gin.H{
 			"data": message,
 			"type": "notification",
 		}); err != nil {
@@ -115,27 +121,51 @@ func SendWebSocketNotification(userID uint, message model.Notification) {
 	}
 }
 
-func SendNewPostSignal(postID uint, userIDUint uint) {
+// SendDirectMessage pushes a private message to the user.
+func SendDirectMessage(userID uint, message model.Message) {
+	mu.Lock()
+	client, ok := Users[userID]
+	mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := client.WriteJSON(gin.H{
+		"data": message,
+		"type": "message",
+	}); err != nil {
+		log.Printf("Error writing message: %v", err)
+	}
+}
+
+// SendNewPostSignal tells the users viewing the post that a reply was added,
+// skipping its author and the users in skip.
+func SendNewPostSignal(postID uint, userIDUint uint, skip map[uint]bool) {
 	mu.Lock()
 	clients, ok := Posts[postID]
+	recipients := make([]Client, 0, len(clients))
+	recipientIDs := make([]uint, 0, len(clients))
+	for userID := range clients {
+		if client, connected := Users[userID]; connected && userID != userIDUint && !skip[userID] {
+			recipients = append(recipients, client)
+			recipientIDs = append(recipientIDs, userID)
+		}
+	}
 	mu.Unlock()
 
 	if !ok {
 		return
 	} else {
-		for userID := range clients {
-			if userID == userIDUint || Users[userID].Conn == nil {
-				continue
-			}
-			fmt.Println("Sending post signal to user", userID)
-			if err := Users[userID].Conn.WriteJSON(gin.H{
+		for i, client := range recipients {
+			fmt.Println("Sending post signal to user", recipientIDs[i])
+			if err := client.WriteJSON(gin.H{
 				"type":    "post",
 				"post_id": postID,
 			}); err != nil {
 				mu.Lock()
-				delete(clients, userID)
+				delete(clients, recipientIDs[i])
 				mu.Unlock()
-				Users[userID].Conn.Close()
+				client.Conn.Close()
 			}
 		}
 
@@ -146,3 +176,22 @@ func SendNewPostSignal(postID uint, userIDUint uint) {
 		mu.Unlock()
 	}
 }
+
+// IsConnected reports whether the user has an open websocket connection.
+func IsConnected(userID uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := Users[userID]
+	return ok
+}
+
+// DisconnectUser closes the user's websocket connection, if any.
+func DisconnectUser(userID uint) {
+	mu.Lock()
+	client, ok := Users[userID]
+	mu.Unlock()
+
+	if ok {
+		client.Conn.Close()
+	}
+}